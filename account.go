@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+
+	"github.com/exy63/chirpy/internal/auth"
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/exy63/chirpy/internal/handles"
+	"github.com/google/uuid"
+)
+
+// maxUserPatchBytes caps a PATCH /api/users/me body, which only ever holds
+// a handful of short profile fields.
+const maxUserPatchBytes = 64 << 10
+
+// PrivateUserResponse is the full account as its owner sees it.
+type PrivateUserResponse struct {
+	UserResponse
+	ChirpCount              int64                `json:"chirp_count"`
+	FollowerCount           int64                `json:"follower_count"`
+	FollowingCount          int64                `json:"following_count"`
+	UnreadNotificationCount int64                `json:"unread_notification_count"`
+	UnreadMessageCount      int64                `json:"unread_message_count"`
+	Settings                UserSettingsResponse `json:"settings"`
+	// PendingEmail is an email change waiting for the new address to be
+	// verified.
+	PendingEmail string `json:"pending_email,omitempty"`
+}
+
+type UserSettingsResponse struct {
+	NotificationPreferences NotificationPreferencesResponse `json:"notification_preferences"`
+}
+
+// userPatch is a change to an account. Nil fields are left alone. Setting
+// AvatarMediaID to an invalid NullUUID removes the avatar, and a nil entry
+// in NotificationPreferences resets that type to its default.
+type userPatch struct {
+	Email                        *string
+	Password                     *string
+	Handle                       *string
+	DisplayName                  *string
+	Bio                          *string
+	Website                      *string
+	AvatarMediaID                *uuid.NullUUID
+	NotificationPreferences      map[string]*bool
+	ResetNotificationPreferences bool
+}
+
+// validateUserPatch checks a patch against the current user, so unchanged fields
+// are never rejected by rules added after they were set.
+func (cfg *apiConfig) validateUserPatch(ctx context.Context, user database.User, patch userPatch) error {
+	if patch.Email != nil && *patch.Email == "" {
+		return errors.New("email can't be empty")
+	}
+	if patch.Email != nil && *patch.Email != user.Email {
+		if err := validateEmail(*patch.Email); err != nil {
+			return err
+		}
+	}
+	if patch.Password != nil && *patch.Password == "" {
+		return errors.New("password can't be empty")
+	}
+	if patch.Handle != nil && *patch.Handle != user.Handle {
+		if err := handles.Validate(*patch.Handle); err != nil {
+			return err
+		}
+	}
+	if patch.DisplayName != nil {
+		if err := validateDisplayName(*patch.DisplayName); err != nil {
+			return err
+		}
+	}
+	if patch.Bio != nil {
+		if err := validateBio(*patch.Bio); err != nil {
+			return err
+		}
+	}
+	if patch.Website != nil {
+		if err := validateWebsite(*patch.Website); err != nil {
+			return err
+		}
+	}
+	if patch.AvatarMediaID != nil && patch.AvatarMediaID.Valid && patch.AvatarMediaID.UUID != user.AvatarMediaID.UUID {
+		attachment, err := cfg.dbQueries.GetMediaAttachment(ctx, patch.AvatarMediaID.UUID)
+		if err != nil || attachment.UserID != user.ID || attachment.ChirpID.Valid {
+			return errors.New("Media " + patch.AvatarMediaID.UUID.String() + " not found or already attached")
+		}
+	}
+	for notificationType := range patch.NotificationPreferences {
+		if !slices.Contains(notificationTypes, notificationType) {
+			return fmt.Errorf("%q is not a notification type", notificationType)
+		}
+	}
+	return nil
+}
+
+// applyUserPatch writes a validated patch with the queries of the caller's
+// transaction. The email is left alone: a new address only replaces the
+// old one once it has been verified.
+func applyUserPatch(ctx context.Context, q *database.Queries, user database.User, patch userPatch) (database.User, error) {
+	params := database.UpdateUserParams{
+		ID:             user.ID,
+		Email:          user.Email,
+		HashedPassword: user.HashedPassword,
+		Handle:         user.Handle,
+		DisplayName:    user.DisplayName,
+		Bio:            user.Bio,
+		Website:        user.Website,
+		AvatarMediaID:  user.AvatarMediaID,
+	}
+	if patch.Password != nil {
+		hashedPassword, err := auth.HashPassword(*patch.Password)
+		if err != nil {
+			return database.User{}, err
+		}
+		params.HashedPassword = sql.NullString{String: hashedPassword, Valid: true}
+	}
+	if patch.Handle != nil {
+		params.Handle = *patch.Handle
+	}
+	if patch.DisplayName != nil {
+		params.DisplayName = *patch.DisplayName
+	}
+	if patch.Bio != nil {
+		params.Bio = *patch.Bio
+	}
+	if patch.Website != nil {
+		params.Website = *patch.Website
+	}
+	if patch.AvatarMediaID != nil {
+		params.AvatarMediaID = *patch.AvatarMediaID
+	}
+
+	updatedUser, err := q.UpdateUser(ctx, params)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	if patch.ResetNotificationPreferences {
+		if err := q.DeleteNotificationPreferences(ctx, user.ID); err != nil {
+			return database.User{}, err
+		}
+	}
+	for notificationType, enabled := range patch.NotificationPreferences {
+		if enabled == nil {
+			params := database.DeleteNotificationPreferenceParams{
+				UserID: user.ID,
+				Type:   notificationType,
+			}
+			if err := q.DeleteNotificationPreference(ctx, params); err != nil {
+				return database.User{}, err
+			}
+			continue
+		}
+		params := database.SetNotificationPreferenceParams{
+			UserID:  user.ID,
+			Type:    notificationType,
+			Enabled: *enabled,
+		}
+		if err := q.SetNotificationPreference(ctx, params); err != nil {
+			return database.User{}, err
+		}
+	}
+
+	return updatedUser, nil
+}
+
+// updateUser validates and applies a patch in one transaction and writes
+// the updated account, or the reason it was refused, to w.
+func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID, patch userPatch, respond func(context.Context, database.User) (interface{}, error)) {
+	user, err := cfg.dbQueries.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if err := cfg.validateUserPatch(r.Context(), user, patch); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	updatedUser, err := applyUserPatch(r.Context(), qtx, user, patch)
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Email or handle is already taken")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	verificationToken := ""
+	switch {
+	case patch.Email != nil && *patch.Email != user.Email:
+		if _, err := qtx.GetUserByEmail(r.Context(), *patch.Email); err == nil {
+			respondWithError(w, http.StatusConflict, "Email or handle is already taken")
+			return
+		}
+		verificationToken, err = issueEmailVerification(r.Context(), qtx, user.ID, *patch.Email)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	case patch.Email != nil && user.EmailVerifiedAt.Valid:
+		// Setting the current address again cancels a pending change.
+		if err := qtx.DeleteEmailVerificationTokens(r.Context(), user.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if verificationToken != "" {
+		cfg.sendEmailVerification(r, *patch.Email, verificationToken)
+	}
+
+	res, err := respond(r.Context(), updatedUser)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+func (cfg *apiConfig) newPrivateUserResponse(ctx context.Context, user database.User) (PrivateUserResponse, error) {
+	userResponse, err := cfg.newUserResponse(ctx, user)
+	if err != nil {
+		return PrivateUserResponse{}, err
+	}
+	stats, err := cfg.dbQueries.GetUserStats(ctx, user.ID)
+	if err != nil {
+		return PrivateUserResponse{}, err
+	}
+	unreadNotifications, err := cfg.dbQueries.CountUnreadNotifications(ctx, user.ID)
+	if err != nil {
+		return PrivateUserResponse{}, err
+	}
+	unreadMessages, err := cfg.dbQueries.CountUnreadMessages(ctx, database.CountUnreadMessagesParams{UserID: user.ID})
+	if err != nil {
+		return PrivateUserResponse{}, err
+	}
+	preferences, err := cfg.notificationPreferences(ctx, user.ID)
+	if err != nil {
+		return PrivateUserResponse{}, err
+	}
+	pendingEmail := ""
+	pending, err := cfg.dbQueries.GetPendingEmailVerification(ctx, user.ID)
+	if err == nil && pending.Email != user.Email {
+		pendingEmail = pending.Email
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return PrivateUserResponse{}, err
+	}
+
+	return PrivateUserResponse{
+		UserResponse:            userResponse,
+		ChirpCount:              stats.ChirpCount,
+		FollowerCount:           stats.FollowerCount,
+		FollowingCount:          stats.FollowingCount,
+		UnreadNotificationCount: unreadNotifications,
+		UnreadMessageCount:      unreadMessages,
+		Settings: UserSettingsResponse{
+			NotificationPreferences: preferences,
+		},
+		PendingEmail: pendingEmail,
+	}, nil
+}
+
+func (cfg *apiConfig) handlerGetMe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUser(r.Context(), UserID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	userResponse, err := cfg.newPrivateUserResponse(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, userResponse)
+}
+
+// handlerPatchMe updates the account with JSON Merge Patch (RFC 7386)
+// semantics: members left out are unchanged and members set to null are
+// cleared. Email, password and handle can be changed but not cleared, and
+// a new email only takes effect once it has been verified.
+func (cfg *apiConfig) handlerPatchMe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "application/merge-patch+json" && mediaType != "application/json") {
+			respondWithError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/merge-patch+json")
+			return
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxUserPatchBytes))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body format")
+		return
+	}
+	patch, err := parseUserMergePatch(body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cfg.updateUser(w, r, UserID, patch, func(ctx context.Context, user database.User) (interface{}, error) {
+		return cfg.newPrivateUserResponse(ctx, user)
+	})
+}
+
+// parseUserMergePatch turns a merge patch document into a userPatch. JSON
+// null has to be told apart from a missing member, so members are decoded
+// one at a time.
+func parseUserMergePatch(body []byte) (userPatch, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return userPatch{}, errors.New("Request body must be a JSON object")
+	}
+
+	var patch userPatch
+	for name, raw := range members {
+		isNull := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+
+		switch name {
+		case "email", "password", "handle":
+			if isNull {
+				return userPatch{}, fmt.Errorf("%s can't be removed", name)
+			}
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return userPatch{}, fmt.Errorf("%s must be a string", name)
+			}
+			switch name {
+			case "email":
+				patch.Email = &value
+			case "password":
+				patch.Password = &value
+			case "handle":
+				patch.Handle = &value
+			}
+		case "display_name", "bio", "website":
+			var value string
+			if !isNull {
+				if err := json.Unmarshal(raw, &value); err != nil {
+					return userPatch{}, fmt.Errorf("%s must be a string", name)
+				}
+			}
+			switch name {
+			case "display_name":
+				patch.DisplayName = &value
+			case "bio":
+				patch.Bio = &value
+			case "website":
+				patch.Website = &value
+			}
+		case "avatar_media_id":
+			avatarMediaID := uuid.NullUUID{}
+			if !isNull {
+				if err := json.Unmarshal(raw, &avatarMediaID.UUID); err != nil {
+					return userPatch{}, errors.New("avatar_media_id must be a UUID")
+				}
+				avatarMediaID.Valid = true
+			}
+			patch.AvatarMediaID = &avatarMediaID
+		case "settings":
+			if err := parseSettingsMergePatch(raw, isNull, &patch); err != nil {
+				return userPatch{}, err
+			}
+		default:
+			return userPatch{}, fmt.Errorf("%s can't be changed", name)
+		}
+	}
+
+	return patch, nil
+}
+
+func parseSettingsMergePatch(raw json.RawMessage, isNull bool, patch *userPatch) error {
+	if isNull {
+		patch.ResetNotificationPreferences = true
+		return nil
+	}
+
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return errors.New("settings must be an object")
+	}
+	for name, raw := range settings {
+		if name != "notification_preferences" {
+			return fmt.Errorf("settings.%s can't be changed", name)
+		}
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			patch.ResetNotificationPreferences = true
+			continue
+		}
+		if err := json.Unmarshal(raw, &patch.NotificationPreferences); err != nil {
+			return errors.New("settings.notification_preferences must map notification types to true, false or null")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUserMergePatch(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+	boolPtr := func(b bool) *bool { return &b }
+	avatarID := uuid.MustParse("0b6f4d3e-6a8f-4a4e-9d63-3c2d1a7e5f10")
+
+	tests := []struct {
+		name    string
+		body    string
+		want    userPatch
+		wantErr string
+	}{
+		{name: "empty object leaves everything alone", body: `{}`, want: userPatch{}},
+		{
+			name: "strings are set",
+			body: `{"email": "walt@example.com", "password": "hunter2", "handle": "heisenberg", "display_name": "Walt", "bio": "Chemistry teacher", "website": "https://example.com"}`,
+			want: userPatch{
+				Email:       ptr("walt@example.com"),
+				Password:    ptr("hunter2"),
+				Handle:      ptr("heisenberg"),
+				DisplayName: ptr("Walt"),
+				Bio:         ptr("Chemistry teacher"),
+				Website:     ptr("https://example.com"),
+			},
+		},
+		{
+			name: "null clears optional profile fields",
+			body: `{"display_name": null, "bio": null, "website": null}`,
+			want: userPatch{DisplayName: ptr(""), Bio: ptr(""), Website: ptr("")},
+		},
+		{name: "null email", body: `{"email": null}`, wantErr: "email can't be removed"},
+		{name: "null password", body: `{"password": null}`, wantErr: "password can't be removed"},
+		{name: "null handle", body: `{"handle": null}`, wantErr: "handle can't be removed"},
+		{name: "number for a string", body: `{"bio": 42}`, wantErr: "bio must be a string"},
+		{name: "object for a string", body: `{"email": {"address": "walt@example.com"}}`, wantErr: "email must be a string"},
+		{
+			name: "avatar is set",
+			body: `{"avatar_media_id": "` + avatarID.String() + `"}`,
+			want: userPatch{AvatarMediaID: &uuid.NullUUID{UUID: avatarID, Valid: true}},
+		},
+		{name: "null avatar removes it", body: `{"avatar_media_id": null}`, want: userPatch{AvatarMediaID: &uuid.NullUUID{}}},
+		{name: "avatar that isn't a UUID", body: `{"avatar_media_id": "selfie.png"}`, wantErr: "avatar_media_id must be a UUID"},
+		{name: "unknown member", body: `{"is_chirpy_red": true}`, wantErr: "is_chirpy_red can't be changed"},
+		{name: "array body", body: `[]`, wantErr: "Request body must be a JSON object"},
+		{name: "null body", body: `null`, wantErr: "Request body must be a JSON object"},
+		{name: "invalid JSON", body: `{"bio":`, wantErr: "Request body must be a JSON object"},
+		{name: "null settings resets preferences", body: `{"settings": null}`, want: userPatch{ResetNotificationPreferences: true}},
+		{name: "empty settings", body: `{"settings": {}}`, want: userPatch{}},
+		{name: "settings that aren't an object", body: `{"settings": "quiet"}`, wantErr: "settings must be an object"},
+		{name: "unknown setting", body: `{"settings": {"theme": "dark"}}`, wantErr: "settings.theme can't be changed"},
+		{
+			name: "null notification_preferences resets them",
+			body: `{"settings": {"notification_preferences": null}}`,
+			want: userPatch{ResetNotificationPreferences: true},
+		},
+		{
+			name: "notification preferences are merged",
+			body: `{"settings": {"notification_preferences": {"like": false, "follow": true, "mention": null}}}`,
+			want: userPatch{NotificationPreferences: map[string]*bool{"like": boolPtr(false), "follow": boolPtr(true), "mention": nil}},
+		},
+		{
+			name:    "notification preference that isn't a boolean",
+			body:    `{"settings": {"notification_preferences": {"like": "off"}}}`,
+			wantErr: "settings.notification_preferences must map notification types to true, false or null",
+		},
+		{
+			name:    "notification_preferences that aren't an object",
+			body:    `{"settings": {"notification_preferences": [true]}}`,
+			wantErr: "settings.notification_preferences must map notification types to true, false or null",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := parseUserMergePatch([]byte(tt.body))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, patch)
+		})
+	}
+}
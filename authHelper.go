@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/exy63/chirpy/internal/auth"
+	"github.com/google/uuid"
+)
+
+// viewerID identifies the caller on endpoints that work both with and
+// without a token. A missing or invalid token means an anonymous viewer.
+func (cfg *apiConfig) viewerID(r *http.Request) uuid.NullUUID {
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	userID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}
+}
+
+// isAdmin reports whether the request carries the admin API key. With no
+// key configured, nobody is an admin.
+func (cfg *apiConfig) isAdmin(r *http.Request) bool {
+	apiKey, err := auth.GetAPIKey(r.Header)
+	return err == nil && cfg.adminKey != "" && apiKey == cfg.adminKey
+}
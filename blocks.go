@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/exy63/chirpy/internal/auth"
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type BlockResponse struct {
+	UserID    uuid.UUID `json:"user_id"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+type BlocksPageResponse struct {
+	Users      []BlockResponse `json:"users"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+type MuteResponse struct {
+	UserID  uuid.UUID `json:"user_id"`
+	MutedAt time.Time `json:"muted_at"`
+}
+
+type MutesPageResponse struct {
+	Users      []MuteResponse `json:"users"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// isBlockedBetween reports whether userID has blocked, or been blocked by,
+// any of otherUserIDs. A block in either direction stops replies, follows
+// and direct messages between the two.
+func (cfg *apiConfig) isBlockedBetween(ctx context.Context, userID uuid.UUID, otherUserIDs ...uuid.UUID) (bool, error) {
+	if len(otherUserIDs) == 0 {
+		return false, nil
+	}
+	params := database.IsBlockedBetweenParams{
+		UserID:       userID,
+		OtherUserIds: otherUserIDs,
+	}
+	return cfg.dbQueries.IsBlockedBetween(ctx, params)
+}
+
+// handlerBlockUser blocks {id} and removes any follows between the two
+// users, so neither keeps the other in their timeline.
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id := r.PathValue("id")
+	blockedID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if blockedID == UserID {
+		respondWithError(w, http.StatusBadRequest, "You can't block yourself")
+		return
+	}
+
+	if _, err := cfg.dbQueries.GetUser(r.Context(), blockedID); err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	blockParams := database.BlockUserParams{
+		BlockerID: UserID,
+		BlockedID: blockedID,
+	}
+	blocked, err := qtx.BlockUser(r.Context(), blockParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if blocked == 0 {
+		respondWithError(w, http.StatusConflict, "You have already blocked this user")
+		return
+	}
+
+	followsParams := database.DeleteFollowsBetweenParams{
+		UserID:      UserID,
+		OtherUserID: blockedID,
+	}
+	if err := qtx.DeleteFollowsBetween(r.Context(), followsParams); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id := r.PathValue("id")
+	blockedID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.UnblockUserParams{
+		BlockerID: UserID,
+		BlockedID: blockedID,
+	}
+	unblocked, err := cfg.dbQueries.UnblockUser(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if unblocked == 0 {
+		respondWithError(w, http.StatusNotFound, "You haven't blocked this user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetBlocks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetBlockedUsersParams{
+		UserID: UserID,
+		Limit:  limit + 1,
+	}
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil || cursor.Direction != cursorNext {
+			respondWithError(w, http.StatusBadRequest, "cursor is invalid")
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeUserID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.dbQueries.GetBlockedUsers(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	hasMore := len(rows) > int(limit)
+	if hasMore {
+		rows = rows[:limit]
+	}
+
+	page := BlocksPageResponse{Users: make([]BlockResponse, len(rows))}
+	for i, row := range rows {
+		page.Users[i] = BlockResponse{UserID: row.UserID, BlockedAt: row.CreatedAt}
+	}
+	if hasMore {
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeCursor(cursorNext, last.CreatedAt, last.UserID)
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// handlerMuteUser hides {id}'s chirps from the caller's listings without
+// telling them or restricting what they can do.
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id := r.PathValue("id")
+	mutedID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if mutedID == UserID {
+		respondWithError(w, http.StatusBadRequest, "You can't mute yourself")
+		return
+	}
+
+	if _, err := cfg.dbQueries.GetUser(r.Context(), mutedID); err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	params := database.MuteUserParams{
+		MuterID: UserID,
+		MutedID: mutedID,
+	}
+	muted, err := cfg.dbQueries.MuteUser(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if muted == 0 {
+		respondWithError(w, http.StatusConflict, "You have already muted this user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id := r.PathValue("id")
+	mutedID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.UnmuteUserParams{
+		MuterID: UserID,
+		MutedID: mutedID,
+	}
+	unmuted, err := cfg.dbQueries.UnmuteUser(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if unmuted == 0 {
+		respondWithError(w, http.StatusNotFound, "You haven't muted this user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetMutes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetMutedUsersParams{
+		UserID: UserID,
+		Limit:  limit + 1,
+	}
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil || cursor.Direction != cursorNext {
+			respondWithError(w, http.StatusBadRequest, "cursor is invalid")
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeUserID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.dbQueries.GetMutedUsers(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	hasMore := len(rows) > int(limit)
+	if hasMore {
+		rows = rows[:limit]
+	}
+
+	page := MutesPageResponse{Users: make([]MuteResponse, len(rows))}
+	for i, row := range rows {
+		page.Users[i] = MuteResponse{UserID: row.UserID, MutedAt: row.CreatedAt}
+	}
+	if hasMore {
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeCursor(cursorNext, last.CreatedAt, last.UserID)
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	sortByCreatedAt = "created_at"
+	sortByLikes     = "likes"
+)
+
+type InvalidParam struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type InvalidParamsResponse struct {
+	Error         string         `json:"error"`
+	InvalidParams []InvalidParam `json:"invalid_params"`
+}
+
+// invalidParams collects every bad query parameter of a request, so the
+// client can fix them all at once.
+type invalidParams []InvalidParam
+
+func (p *invalidParams) add(field, message string) {
+	*p = append(*p, InvalidParam{Field: field, Message: message})
+}
+
+func respondWithInvalidParams(w http.ResponseWriter, params invalidParams) {
+	res, _ := json.Marshal(InvalidParamsResponse{Error: "Invalid query parameters", InvalidParams: params})
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(res)
+}
+
+// chirpFilter narrows a chirp listing. An empty filter matches every
+// published chirp.
+type chirpFilter struct {
+	AuthorIDs []uuid.UUID
+	Since     sql.NullTime
+	Until     sql.NullTime
+	Contains  sql.NullString
+	// ViewerID hides authors the viewer has blocked or muted, or who have
+	// blocked them.
+	ViewerID uuid.NullUUID
+}
+
+// chirpListOptions is a parsed GET /api/chirps request. Listings sorted by
+// created_at page with a keyset cursor; listings sorted by likes page by
+// offset, as like counts change while a client is paging.
+type chirpListOptions struct {
+	Filter     chirpFilter
+	SortBy     string
+	Descending bool
+	Limit      int32
+	Cursor     *pageCursor
+	Offset     int32
+}
+
+func parseChirpListOptions(query url.Values) (chirpListOptions, invalidParams) {
+	var options chirpListOptions
+	var invalid invalidParams
+
+	authorIDs, err := parseAuthorIDs(query["author_id"])
+	if err != nil {
+		invalid.add("author_id", err.Error())
+	}
+	options.Filter.AuthorIDs = authorIDs
+
+	if since := query.Get("since"); since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			invalid.add("since", "since must be an RFC 3339 timestamp")
+		} else {
+			options.Filter.Since = sql.NullTime{Time: parsed.UTC(), Valid: true}
+		}
+	}
+	if until := query.Get("until"); until != "" {
+		parsed, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			invalid.add("until", "until must be an RFC 3339 timestamp")
+		} else {
+			options.Filter.Until = sql.NullTime{Time: parsed.UTC(), Valid: true}
+		}
+	}
+	if options.Filter.Since.Valid && options.Filter.Until.Valid && !options.Filter.Since.Time.Before(options.Filter.Until.Time) {
+		invalid.add("until", "until must be after since")
+	}
+
+	if contains := strings.TrimSpace(query.Get("contains")); contains != "" {
+		options.Filter.Contains = sql.NullString{String: escapeLikePattern(contains), Valid: true}
+	}
+
+	switch sortBy := query.Get("sort_by"); sortBy {
+	case "", sortByCreatedAt:
+		options.SortBy = sortByCreatedAt
+	case sortByLikes:
+		options.SortBy = sortByLikes
+	default:
+		invalid.add("sort_by", "sort_by must be created_at or likes")
+	}
+
+	switch sort := query.Get("sort"); sort {
+	case "", "asc":
+	case "desc":
+		options.Descending = true
+	default:
+		invalid.add("sort", "sort must be asc or desc")
+	}
+
+	options.Limit, err = parsePageLimit(query.Get("limit"))
+	if err != nil {
+		invalid.add("limit", err.Error())
+	}
+
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		if options.SortBy == sortByLikes {
+			options.Offset, err = decodeOffsetCursor(rawCursor)
+		} else {
+			var cursor pageCursor
+			cursor, err = decodeCursor(rawCursor)
+			options.Cursor = &cursor
+		}
+		if err != nil {
+			invalid.add("cursor", err.Error())
+		}
+	}
+
+	return options, invalid
+}
+
+// parseAuthorIDs reads author_id values, given as repeated parameters,
+// comma-separated, or both. No values give an empty, non-nil slice, since
+// a nil slice reaches Postgres as NULL rather than as an empty array.
+func parseAuthorIDs(values []string) ([]uuid.UUID, error) {
+	authorIDs := []uuid.UUID{}
+	for _, value := range values {
+		for _, authorID := range strings.Split(value, ",") {
+			parsedUUID, err := uuid.Parse(strings.TrimSpace(authorID))
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a valid author ID", authorID)
+			}
+			authorIDs = append(authorIDs, parsedUUID)
+		}
+	}
+	return authorIDs, nil
+}
+
+// escapeLikePattern makes s match itself literally inside an ILIKE pattern.
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChirpListOptionsDefaults(t *testing.T) {
+	options, invalid := parseChirpListOptions(url.Values{})
+	require.Empty(t, invalid)
+
+	// A nil slice would reach Postgres as NULL and match no chirps at all.
+	assert.NotNil(t, options.Filter.AuthorIDs)
+	assert.Empty(t, options.Filter.AuthorIDs)
+	assert.False(t, options.Filter.Since.Valid)
+	assert.False(t, options.Filter.Until.Valid)
+	assert.False(t, options.Filter.Contains.Valid)
+	assert.Equal(t, sortByCreatedAt, options.SortBy)
+	assert.False(t, options.Descending)
+	assert.Equal(t, int32(defaultPageLimit), options.Limit)
+	assert.Nil(t, options.Cursor)
+}
+
+func TestParseChirpListOptionsAuthorIDs(t *testing.T) {
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+
+	options, invalid := parseChirpListOptions(url.Values{
+		"author_id": {first.String() + ", " + second.String(), third.String()},
+	})
+	require.Empty(t, invalid)
+	assert.Equal(t, []uuid.UUID{first, second, third}, options.Filter.AuthorIDs)
+}
+
+func TestParseChirpListOptionsFilters(t *testing.T) {
+	options, invalid := parseChirpListOptions(url.Values{
+		"since":    {"2024-01-01T00:00:00+02:00"},
+		"until":    {"2024-02-01T00:00:00Z"},
+		"contains": {"  100%_done  "},
+		"sort_by":  {"likes"},
+		"sort":     {"desc"},
+		"limit":    {"5"},
+	})
+	require.Empty(t, invalid)
+
+	assert.Equal(t, "2023-12-31T22:00:00Z", options.Filter.Since.Time.Format("2006-01-02T15:04:05Z07:00"))
+	assert.True(t, options.Filter.Until.Valid)
+	assert.Equal(t, `100\%\_done`, options.Filter.Contains.String)
+	assert.Equal(t, sortByLikes, options.SortBy)
+	assert.True(t, options.Descending)
+	assert.Equal(t, int32(5), options.Limit)
+}
+
+func TestParseChirpListOptionsReportsEveryInvalidParam(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  url.Values
+		fields []string
+	}{
+		{
+			name:   "bad author ID",
+			query:  url.Values{"author_id": {uuid.NewString() + ",walt"}},
+			fields: []string{"author_id"},
+		},
+		{
+			name:   "until before since",
+			query:  url.Values{"since": {"2024-02-01T00:00:00Z"}, "until": {"2024-01-01T00:00:00Z"}},
+			fields: []string{"until"},
+		},
+		{
+			name: "everything wrong at once",
+			query: url.Values{
+				"author_id": {"walt"},
+				"since":     {"yesterday"},
+				"until":     {"tomorrow"},
+				"sort_by":   {"views"},
+				"sort":      {"sideways"},
+				"limit":     {"0"},
+				"cursor":    {"not a cursor"},
+			},
+			fields: []string{"author_id", "since", "until", "sort_by", "sort", "limit", "cursor"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, invalid := parseChirpListOptions(tt.query)
+			var fields []string
+			for _, param := range invalid {
+				fields = append(fields, param.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/exy63/chirpy/internal/graphemes"
+	"github.com/exy63/chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+const (
+	maxChirpLength                 = 140
+	defaultChirpyRedMaxChirpLength = 500
+	// urlLength is what every link counts as, however long it is.
+	urlLength = 23
+)
+
+var urlPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s]+`)
+
+// chirpPolicy is what getCleanedBody checks a body against for one author.
+type chirpPolicy struct {
+	MaxLength int
+	Rules     []moderation.Rule
+}
+
+type chirpTooLongError struct {
+	Length int
+	Limit  int
+}
+
+func (e chirpTooLongError) Error() string {
+	return fmt.Sprintf("chirp is too long: %d characters, the limit is %d", e.Length, e.Limit)
+}
+
+type ChirpTooLongResponse struct {
+	Error  string `json:"error"`
+	Length int    `json:"length"`
+	Limit  int    `json:"limit"`
+}
+
+// chirpLength counts a body the way users see it: in grapheme clusters, so
+// an emoji or an accented letter is one character, with each link counted
+// as urlLength characters.
+func chirpLength(body string) int {
+	length := 0
+	last := 0
+	for _, match := range urlPattern.FindAllStringIndex(body, -1) {
+		length += graphemes.Count(body[last:match[0]]) + urlLength
+		last = match[1]
+	}
+	return length + graphemes.Count(body[last:])
+}
+
+func (cfg *apiConfig) chirpPolicy(ctx context.Context, userID uuid.UUID) (chirpPolicy, error) {
+	user, err := cfg.dbQueries.GetUser(ctx, userID)
+	if err != nil {
+		return chirpPolicy{}, err
+	}
+	rules, err := cfg.moderationRules(ctx)
+	if err != nil {
+		return chirpPolicy{}, err
+	}
+
+	policy := chirpPolicy{MaxLength: maxChirpLength, Rules: rules}
+	if hasChirpyRed(user) {
+		policy.MaxLength = cfg.chirpyRedMaxChirpLength
+	}
+	return policy, nil
+}
+
+// respondWithBodyError reports a body rejected by getCleanedBody, including
+// the computed length and the limit when it was too long.
+func respondWithBodyError(w http.ResponseWriter, err error) {
+	var tooLong chirpTooLongError
+	if !errors.As(err, &tooLong) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, _ := json.Marshal(ChirpTooLongResponse{Error: err.Error(), Length: tooLong.Length, Limit: tooLong.Limit})
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(res)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type ChirpRevisionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+func (cfg *apiConfig) handlerGetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	id := r.PathValue("id")
+	chirpID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpID)
+	if err != nil || !chirpVisibleTo(chirp, viewerID) {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+	hiddenParams := database.IsAuthorHiddenParams{
+		AuthorID: chirp.UserID,
+		ViewerID: viewerID,
+	}
+	hidden, err := cfg.dbQueries.IsAuthorHidden(r.Context(), hiddenParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if hidden {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+
+	revisions, err := cfg.dbQueries.GetChirpRevisions(r.Context(), chirp.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	revisionsResponse := make([]ChirpRevisionResponse, len(revisions))
+	for i, revision := range revisions {
+		revisionsResponse[i] = ChirpRevisionResponse{
+			ID:         revision.ID,
+			ChirpID:    revision.ChirpID,
+			Body:       revision.Body,
+			CreatedAt:  revision.CreatedAt,
+			ReplacedAt: revision.ReplacedAt,
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, revisionsResponse)
+}
@@ -1,11 +1,11 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
-	"errors"
 	"net/http"
-	"sort"
-	"strings"
+	"slices"
 	"time"
 
 	"github.com/exy63/chirpy/internal/auth"
@@ -14,11 +14,95 @@ import (
 )
 
 type ChirpResponse struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    string    `json:"user_id"`
+	ID             uuid.UUID       `json:"id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	Body           string          `json:"body"`
+	UserID         string          `json:"user_id"`
+	Edited         bool            `json:"edited"`
+	EditedAt       *time.Time      `json:"edited_at,omitempty"`
+	ParentID       *uuid.UUID      `json:"parent_id,omitempty"`
+	ConversationID uuid.UUID       `json:"conversation_id"`
+	Deleted        bool            `json:"deleted"`
+	LikeCount      int64           `json:"like_count"`
+	LikedByMe      bool            `json:"liked_by_me"`
+	RechirpOfID    *uuid.UUID      `json:"rechirp_of_id,omitempty"`
+	RechirpOf      *ChirpResponse  `json:"rechirp_of,omitempty"`
+	QuoteOfID      *uuid.UUID      `json:"quote_of_id,omitempty"`
+	QuoteOf        *ChirpResponse  `json:"quote_of,omitempty"`
+	RechirpCount   int64           `json:"rechirp_count"`
+	QuoteCount     int64           `json:"quote_count"`
+	Media          []MediaResponse `json:"media,omitempty"`
+	Scheduled      bool            `json:"scheduled"`
+	PublishAt      *time.Time      `json:"publish_at,omitempty"`
+	// ModerationStatus is only set while a chirp is held for review or
+	// after it has been rejected.
+	ModerationStatus string          `json:"moderation_status,omitempty"`
+	Author           *AuthorResponse `json:"author,omitempty"`
+}
+
+type ChirpsPageResponse struct {
+	Chirps     []ChirpResponse `json:"chirps"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	PrevCursor string          `json:"prev_cursor,omitempty"`
+}
+
+func newChirpResponse(chirp database.Chirp) ChirpResponse {
+	chirpResponse := ChirpResponse{
+		ID:             chirp.ID,
+		CreatedAt:      chirp.CreatedAt,
+		UpdatedAt:      chirp.UpdatedAt,
+		Body:           chirp.Body,
+		UserID:         chirp.UserID.String(),
+		Edited:         chirp.EditedAt.Valid,
+		ConversationID: chirp.RootID,
+		Deleted:        chirp.DeletedAt.Valid,
+	}
+	if chirp.EditedAt.Valid {
+		chirpResponse.EditedAt = &chirp.EditedAt.Time
+	}
+	if chirp.ParentID.Valid {
+		chirpResponse.ParentID = &chirp.ParentID.UUID
+	}
+	if chirp.RechirpOfID.Valid {
+		chirpResponse.RechirpOfID = &chirp.RechirpOfID.UUID
+	}
+	if chirp.QuoteOfID.Valid {
+		chirpResponse.QuoteOfID = &chirp.QuoteOfID.UUID
+	}
+	if !chirp.Published && chirp.PublishAt.Valid {
+		chirpResponse.Scheduled = true
+		chirpResponse.PublishAt = &chirp.PublishAt.Time
+	}
+	if chirp.ModerationStatus != moderationApproved {
+		chirpResponse.ModerationStatus = chirp.ModerationStatus
+	}
+	return chirpResponse
+}
+
+// enrichChirps fills in the parts of a batch of chirp responses that live
+// outside the chirps row: the chirps they share, their authors, like,
+// rechirp and quote counts, and media. Each step costs one query for the whole batch.
+func (cfg *apiConfig) enrichChirps(ctx context.Context, viewerID uuid.NullUUID, chirps []*ChirpResponse) error {
+	embedded, err := cfg.withReferencedChirps(ctx, viewerID, chirps)
+	if err != nil {
+		return err
+	}
+
+	batch := make([]*ChirpResponse, 0, len(chirps)+len(embedded))
+	batch = append(batch, chirps...)
+	batch = append(batch, embedded...)
+
+	if err := cfg.withAuthors(ctx, batch); err != nil {
+		return err
+	}
+	if err := cfg.withLikeStats(ctx, viewerID, batch); err != nil {
+		return err
+	}
+	if err := cfg.withShareCounts(ctx, batch); err != nil {
+		return err
+	}
+	return cfg.withMedia(ctx, batch)
 }
 
 func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, r *http.Request) {
@@ -32,60 +116,165 @@ func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	viewerID := cfg.viewerID(r)
 	chirp, err := cfg.dbQueries.GetChirp(r.Context(), uuid)
+	if err != nil || !chirpVisibleTo(chirp, viewerID) {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+	hiddenParams := database.IsAuthorHiddenParams{
+		AuthorID: chirp.UserID,
+		ViewerID: viewerID,
+	}
+	hidden, err := cfg.dbQueries.IsAuthorHidden(r.Context(), hiddenParams)
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, err.Error())
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if hidden {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
 		return
 	}
 
-	chirpResponse := ChirpResponse{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID.String(),
+	chirpResponse := newChirpResponse(chirp)
+	if err := cfg.enrichChirps(r.Context(), viewerID, []*ChirpResponse{&chirpResponse}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	respondWithJSON(w, http.StatusOK, chirpResponse)
+	// Like and share counts, the quoted chirp and the author's profile all
+	// change without touching the chirp's updated_at, so it is validated by
+	// ETag only.
+	respondWithCacheableJSON(w, r, viewerID, chirpResponse, time.Time{})
 }
 
 func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	defer r.Body.Close()
 
-	authorID := r.URL.Query().Get("author_id")
-	userIDParam := uuid.NullUUID{}
-	if authorID != "" {
-		parsedUUID, err := uuid.Parse(authorID)
-		if err == nil {
-			userIDParam = uuid.NullUUID{UUID: parsedUUID, Valid: true}
-		}
+	options, invalid := parseChirpListOptions(r.URL.Query())
+	if len(invalid) > 0 {
+		respondWithInvalidParams(w, invalid)
+		return
 	}
 
-	chirps, err := cfg.dbQueries.GetChirps(r.Context(), userIDParam)
+	viewerID := cfg.viewerID(r)
+	options.Filter.ViewerID = viewerID
+
+	var chirps []database.Chirp
+	var hasMore bool
+	var err error
+	if options.SortBy == sortByLikes {
+		chirps, hasMore, err = cfg.getChirpsByLikesPage(r.Context(), options)
+	} else {
+		chirps, hasMore, err = cfg.getChirpsPage(r.Context(), options.Filter, options.Descending, options.Cursor, options.Limit)
+	}
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error())
+		respondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	chirpResponse := make([]ChirpResponse, len(chirps))
+	page := ChirpsPageResponse{Chirps: make([]ChirpResponse, len(chirps))}
+	chirpResponses := make([]*ChirpResponse, len(chirps))
 	for i, chirp := range chirps {
-		chirpResponse[i] = ChirpResponse{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserID:    chirp.UserID.String(),
+		page.Chirps[i] = newChirpResponse(chirp)
+		chirpResponses[i] = &page.Chirps[i]
+	}
+	if err := cfg.enrichChirps(r.Context(), viewerID, chirpResponses); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	switch {
+	case options.SortBy == sortByLikes:
+		if hasMore {
+			page.NextCursor = encodeOffsetCursor(options.Offset + options.Limit)
+		}
+		if options.Offset > 0 {
+			page.PrevCursor = encodeOffsetCursor(max(options.Offset-options.Limit, 0))
 		}
+	case len(chirps) > 0:
+		first, last := chirps[0], chirps[len(chirps)-1]
+		page.NextCursor, page.PrevCursor = pageCursors(options.Cursor, hasMore, first.CreatedAt, first.ID, last.CreatedAt, last.ID)
+	}
+
+	// A page changes when a chirp leaves it or its counts move, neither of
+	// which touches any updated_at, so listings are validated by ETag only.
+	respondWithCacheableJSON(w, r, viewerID, page, time.Time{})
+}
+
+// getChirpsPage fetches one page of chirps in the requested order using the
+// keyset queries. Paging backwards runs the opposite query and reverses the
+// result, so the page always comes back in the order the client asked for.
+func (cfg *apiConfig) getChirpsPage(ctx context.Context, filter chirpFilter, descending bool, cursor *pageCursor, limit int32) ([]database.Chirp, bool, error) {
+	forward := pagesForward(cursor)
+
+	positionCreatedAt := sql.NullTime{}
+	positionID := uuid.NullUUID{}
+	if cursor != nil {
+		positionCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		positionID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
 	}
 
-	if r.URL.Query().Get("sort") == "desc" {
-		sort.Slice(chirpResponse, func(i, j int) bool {
-			return chirpResponse[i].CreatedAt.After(chirpResponse[j].CreatedAt)
+	var chirps []database.Chirp
+	var err error
+	if forward != descending {
+		chirps, err = cfg.dbQueries.GetChirpsAsc(ctx, database.GetChirpsAscParams{
+			UserIds:        filter.AuthorIDs,
+			Since:          filter.Since,
+			Until:          filter.Until,
+			Contains:       filter.Contains,
+			ViewerID:       filter.ViewerID,
+			AfterCreatedAt: positionCreatedAt,
+			AfterID:        positionID,
+			Limit:          limit + 1,
+		})
+	} else {
+		chirps, err = cfg.dbQueries.GetChirpsDesc(ctx, database.GetChirpsDescParams{
+			UserIds:         filter.AuthorIDs,
+			Since:           filter.Since,
+			Until:           filter.Until,
+			Contains:        filter.Contains,
+			ViewerID:        filter.ViewerID,
+			BeforeCreatedAt: positionCreatedAt,
+			BeforeID:        positionID,
+			Limit:           limit + 1,
 		})
 	}
+	if err != nil {
+		return nil, false, err
+	}
 
-	respondWithJSON(w, http.StatusOK, chirpResponse)
+	hasMore := len(chirps) > int(limit)
+	if hasMore {
+		chirps = chirps[:limit]
+	}
+	if !forward {
+		slices.Reverse(chirps)
+	}
+
+	return chirps, hasMore, nil
+}
+
+func (cfg *apiConfig) getChirpsByLikesPage(ctx context.Context, options chirpListOptions) ([]database.Chirp, bool, error) {
+	chirps, err := cfg.dbQueries.GetChirpsByLikes(ctx, database.GetChirpsByLikesParams{
+		UserIds:    options.Filter.AuthorIDs,
+		Since:      options.Filter.Since,
+		Until:      options.Filter.Until,
+		Contains:   options.Filter.Contains,
+		ViewerID:   options.Filter.ViewerID,
+		Descending: options.Descending,
+		Limit:      options.Limit + 1,
+		Offset:     options.Offset,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(chirps) > int(options.Limit)
+	if hasMore {
+		chirps = chirps[:options.Limit]
+	}
+	return chirps, hasMore, nil
 }
 
 func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
@@ -102,10 +291,21 @@ func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
+	if !cfg.requireVerifiedEmail(w, r, UserID) {
+		return
+	}
 
 	type Request struct {
-		Body   string    `json:"body"`
-		UserID uuid.UUID `json:"user_id"`
+		Body      string     `json:"body"`
+		UserID    uuid.UUID  `json:"user_id"`
+		ParentID  *uuid.UUID `json:"parent_id"`
+		RechirpOf *uuid.UUID `json:"rechirp_of"`
+		QuoteOf   *uuid.UUID `json:"quote_of"`
+		Media     []struct {
+			ID      uuid.UUID `json:"id"`
+			AltText string    `json:"alt_text"`
+		} `json:"media"`
+		PublishAt *time.Time `json:"publish_at"`
 	}
 	var req Request
 
@@ -115,54 +315,287 @@ func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	cleanedBody, err := getCleanedBody(req.Body)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error())
+	if req.RechirpOf != nil && (req.QuoteOf != nil || req.ParentID != nil || req.Body != "" || len(req.Media) > 0) {
+		respondWithError(w, http.StatusBadRequest, "A rechirp cannot have a body, a parent, a quote or media")
+		return
+	}
+
+	publishAtParam := sql.NullTime{}
+	if req.PublishAt != nil {
+		if req.RechirpOf != nil {
+			respondWithError(w, http.StatusBadRequest, "Rechirps cannot be scheduled")
+			return
+		}
+		if err := validatePublishAt(*req.PublishAt); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		publishAtParam = sql.NullTime{Time: req.PublishAt.UTC(), Valid: true}
+	}
+
+	if len(req.Media) > maxChirpMedia {
+		respondWithError(w, http.StatusBadRequest, "A chirp can have at most 4 media attachments")
 		return
 	}
+	for _, attachment := range req.Media {
+		if len([]rune(attachment.AltText)) > maxAltTextLength {
+			respondWithError(w, http.StatusBadRequest, "Alt text is too long")
+			return
+		}
+	}
+
+	rechirpOfParam := uuid.NullUUID{}
+	if req.RechirpOf != nil {
+		original, err := cfg.resolveSharedChirp(r.Context(), *req.RechirpOf)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Rechirped chirp not found")
+			return
+		}
+		rechirpOfParam = uuid.NullUUID{UUID: original.ID, Valid: true}
+	}
+
+	quoteOfParam := uuid.NullUUID{}
+	if req.QuoteOf != nil {
+		original, err := cfg.resolveSharedChirp(r.Context(), *req.QuoteOf)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Quoted chirp not found")
+			return
+		}
+		quoteOfParam = uuid.NullUUID{UUID: original.ID, Valid: true}
+	}
+
+	cleaned := cleanedBody{ModerationStatus: moderationApproved}
+	if !rechirpOfParam.Valid {
+		policy, err := cfg.chirpPolicy(r.Context(), UserID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		cleaned, err = getCleanedBody(req.Body, policy)
+		if err != nil {
+			respondWithBodyError(w, err)
+			return
+		}
+	}
+
+	parentIDParam := uuid.NullUUID{}
+	if req.ParentID != nil {
+		parent, err := cfg.dbQueries.GetChirp(r.Context(), *req.ParentID)
+		if err != nil || parent.DeletedAt.Valid || !parent.Published {
+			respondWithError(w, http.StatusNotFound, "Parent chirp not found")
+			return
+		}
+		blocked, err := cfg.isBlockedBetween(r.Context(), UserID, parent.UserID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if blocked {
+			respondWithError(w, http.StatusForbidden, "You can't reply to a user you have blocked or who has blocked you")
+			return
+		}
+		parentIDParam = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
 
 	params := database.CreateChirpParams{
-		Body:   cleanedBody,
-		UserID: UserID,
+		Body:             cleaned.Body,
+		UserID:           UserID,
+		ParentID:         parentIDParam,
+		RechirpOfID:      rechirpOfParam,
+		QuoteOfID:        quoteOfParam,
+		Published:        !publishAtParam.Valid && cleaned.ModerationStatus == moderationApproved,
+		PublishAt:        publishAtParam,
+		OriginalBody:     cleaned.OriginalBody,
+		ModerationStatus: cleaned.ModerationStatus,
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := qtx.CreateChirp(r.Context(), params)
+	if rechirpOfParam.Valid && isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You already rechirped this chirp")
+		return
 	}
-	chirp, err := cfg.dbQueries.CreateChirp(r.Context(), params)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	chirpResponse := ChirpResponse{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID.String(),
+	// Scheduled and held chirps get their hashtags once they are published.
+	if chirp.Published {
+		if err := syncChirpHashtags(r.Context(), qtx, chirp); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err := notifyChirpPublished(r.Context(), qtx, chirp); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	for i, attachment := range req.Media {
+		attachParams := database.AttachMediaToChirpParams{
+			ChirpID:  uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			AltText:  attachment.AltText,
+			Position: int32(i),
+			ID:       attachment.ID,
+			UserID:   UserID,
+		}
+		if _, err := qtx.AttachMediaToChirp(r.Context(), attachParams); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Media "+attachment.ID.String()+" not found or already attached")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if chirp.Published {
+		cfg.broadcastChirpCreated(r.Context(), chirp)
+	}
+
+	chirpResponse := newChirpResponse(chirp)
+	if err := cfg.enrichChirps(r.Context(), uuid.NullUUID{UUID: UserID, Valid: true}, []*ChirpResponse{&chirpResponse}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
 	respondWithJSON(w, http.StatusCreated, chirpResponse)
 }
 
-func getCleanedBody(msg string) (string, error) {
-	const maxChirpLength = 140
-	badWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
+func (cfg *apiConfig) handlerUpdateChirp(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id := r.PathValue("id")
+	chirpID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	if len(msg) > maxChirpLength {
-		return "", errors.New("chirp is too long")
+	type Request struct {
+		Body string `json:"body"`
 	}
+	var req Request
 
-	words := strings.Split(msg, " ")
-	for i, word := range words {
-		loweredWord := strings.ToLower(word)
-		if _, ok := badWords[loweredWord]; ok {
-			words[i] = "****"
-		}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body format")
+		return
+	}
+
+	policy, err := cfg.chirpPolicy(r.Context(), UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	cleaned, err := getCleanedBody(req.Body, policy)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := qtx.GetChirpForUpdate(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+
+	if chirp.UserID != UserID {
+		respondWithError(w, http.StatusForbidden, "You can only edit your own chirps")
+		return
+	}
+
+	if chirp.RechirpOfID.Valid {
+		respondWithError(w, http.StatusBadRequest, "Rechirps cannot be edited")
+		return
+	}
+
+	if chirp.ModerationStatus != moderationApproved {
+		respondWithError(w, http.StatusBadRequest, "Chirps under moderation cannot be edited")
+		return
+	}
+
+	if !chirp.Published {
+		respondWithError(w, http.StatusBadRequest, "Scheduled chirps are edited through /api/scheduled_chirps")
+		return
 	}
 
-	return strings.Join(words, " "), nil
+	if chirp.Body == cleaned.Body && cleaned.ModerationStatus == moderationApproved {
+		respondWithJSON(w, http.StatusOK, newChirpResponse(chirp))
+		return
+	}
+
+	revisionParams := database.CreateChirpRevisionParams{
+		ChirpID:   chirp.ID,
+		Body:      chirp.Body,
+		CreatedAt: chirp.UpdatedAt,
+	}
+	if _, err := qtx.CreateChirpRevision(r.Context(), revisionParams); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	updateParams := database.UpdateChirpBodyParams{
+		Body:             cleaned.Body,
+		OriginalBody:     cleaned.OriginalBody,
+		ModerationStatus: cleaned.ModerationStatus,
+		ID:               chirp.ID,
+	}
+	updatedChirp, err := qtx.UpdateChirpBody(r.Context(), updateParams)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// An edit that is held for review takes the chirp down, hashtags and
+	// all, until a moderator approves it.
+	if updatedChirp.Published {
+		err = syncChirpHashtags(r.Context(), qtx, updatedChirp)
+	} else {
+		err = qtx.DeleteChirpHashtags(r.Context(), updatedChirp.ID)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// An edit held for review takes the chirp off streams as well.
+	if !updatedChirp.Published {
+		cfg.broadcastChirpDeleted(r.Context(), updatedChirp)
+	}
+
+	respondWithJSON(w, http.StatusOK, newChirpResponse(updatedChirp))
 }
 
 func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
@@ -180,14 +613,22 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 	}
 
 	id := r.PathValue("id")
-	uuid, err := uuid.Parse(id)
+	chirpID, err := uuid.Parse(id)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	chirp, err := cfg.dbQueries.GetChirp(r.Context(), uuid)
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
 	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := qtx.GetChirpForUpdate(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
@@ -197,10 +638,53 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := cfg.dbQueries.DeleteChirp(r.Context(), chirp.ID); err != nil {
+	// A chirp with replies is replaced by a tombstone so its thread stays
+	// intact; anything else is removed outright.
+	replyCount, err := qtx.CountChirpReplies(r.Context(), uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	attachments, err := qtx.GetMediaForChirps(r.Context(), []uuid.UUID{chirp.ID})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if replyCount > 0 {
+		if err := qtx.DeleteChirpMedia(r.Context(), uuid.NullUUID{UUID: chirp.ID, Valid: true}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := qtx.DeleteChirpRevisions(r.Context(), chirp.ID); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := qtx.DeleteChirpHashtags(r.Context(), chirp.ID); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err = qtx.TombstoneChirp(r.Context(), chirp.ID)
+	} else {
+		err = qtx.DeleteChirp(r.Context(), chirp.ID)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	for _, attachment := range attachments {
+		cfg.deleteStoredMedia(r.Context(), attachment.StorageKey, attachment.ThumbnailKey)
+	}
+	if chirp.Published {
+		cfg.broadcastChirpDeleted(r.Context(), chirp)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
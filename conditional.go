@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// respondWithCacheableJSON writes payload with validators so clients can
+// revalidate instead of downloading it again.
+func respondWithCacheableJSON(w http.ResponseWriter, r *http.Request, viewerID uuid.NullUUID, payload interface{}, lastModified time.Time) {
+	res, err := json.Marshal(payload)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	respondWithCacheable(w, r, viewerID, "application/json", res, lastModified)
+}
+
+// respondWithCacheable writes body with an ETag, Last-Modified and
+// Cache-Control, or 304 when the client's copy is current. The ETag is a
+// hash of the exact response, so it also changes when counts or embedded
+// chirps do. Last-Modified only tracks the chirps' own updated_at, which is
+// why If-None-Match wins when a client sends both, as RFC 9110 requires. A
+// zero lastModified leaves the header out for responses that updated_at
+// can't describe, such as listings.
+func respondWithCacheable(w http.ResponseWriter, r *http.Request, viewerID uuid.NullUUID, contentType string, body []byte, lastModified time.Time) {
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:18]) + `"`
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	// Responses for a signed-in viewer say whether they liked each chirp,
+	// so shared caches must not hand them to anyone else.
+	header.Set("Vary", "Authorization")
+	if viewerID.Valid {
+		header.Set("Cache-Control", "private, no-cache")
+	} else {
+		header.Set("Cache-Control", "public, no-cache")
+	}
+
+	if notModified(r, etag, lastModified) {
+		header.Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagListMatches(ifNoneMatch, etag)
+	}
+
+	ifModifiedSince := r.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	// HTTP dates have whole seconds.
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// etagListMatches applies the weak comparison If-None-Match calls for to
+// each entity tag in the header.
+func etagListMatches(list string, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
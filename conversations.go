@@ -0,0 +1,762 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/exy63/chirpy/internal/auth"
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/exy63/chirpy/internal/graphemes"
+	"github.com/google/uuid"
+)
+
+const (
+	maxConversationMembers = 50
+	maxDirectMessageLength = 1000
+)
+
+type ConversationMemberResponse struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	LastReadAt *time.Time `json:"last_read_at"`
+}
+
+type ConversationResponse struct {
+	ID             uuid.UUID                    `json:"id"`
+	CreatedAt      time.Time                    `json:"created_at"`
+	UpdatedAt      time.Time                    `json:"updated_at"`
+	LastActivityAt time.Time                    `json:"last_activity_at"`
+	Members        []ConversationMemberResponse `json:"members"`
+	UnreadCount    int64                        `json:"unread_count"`
+}
+
+type ConversationsPageResponse struct {
+	Conversations []ConversationResponse `json:"conversations"`
+	NextCursor    string                 `json:"next_cursor,omitempty"`
+}
+
+type DirectMessageResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	ConversationID uuid.UUID  `json:"conversation_id"`
+	SenderID       uuid.UUID  `json:"sender_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	Body           string     `json:"body"`
+	Edited         bool       `json:"edited"`
+	EditedAt       *time.Time `json:"edited_at,omitempty"`
+	Deleted        bool       `json:"deleted"`
+	// ReadBy lists the other members who have read up to this message.
+	ReadBy []uuid.UUID `json:"read_by"`
+}
+
+type DirectMessagesPageResponse struct {
+	Messages   []DirectMessageResponse `json:"messages"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+}
+
+type UnreadCountResponse struct {
+	UnreadCount int64 `json:"unread_count"`
+}
+
+func newConversationResponse(conversation database.Conversation, members []database.ConversationMember, unreadCount int64) ConversationResponse {
+	conversationResponse := ConversationResponse{
+		ID:             conversation.ID,
+		CreatedAt:      conversation.CreatedAt,
+		UpdatedAt:      conversation.UpdatedAt,
+		LastActivityAt: conversation.LastActivityAt,
+		Members:        make([]ConversationMemberResponse, len(members)),
+		UnreadCount:    unreadCount,
+	}
+	for i, member := range members {
+		conversationResponse.Members[i] = ConversationMemberResponse{
+			UserID:   member.UserID,
+			JoinedAt: member.JoinedAt,
+		}
+		if member.LastReadAt.Valid {
+			conversationResponse.Members[i].LastReadAt = &member.LastReadAt.Time
+		}
+	}
+	return conversationResponse
+}
+
+func newDirectMessageResponse(message database.DirectMessage, members []database.ConversationMember) DirectMessageResponse {
+	messageResponse := DirectMessageResponse{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		CreatedAt:      message.CreatedAt,
+		UpdatedAt:      message.UpdatedAt,
+		Body:           message.Body,
+		Edited:         message.EditedAt.Valid,
+		Deleted:        message.DeletedAt.Valid,
+		ReadBy:         []uuid.UUID{},
+	}
+	if message.EditedAt.Valid {
+		messageResponse.EditedAt = &message.EditedAt.Time
+	}
+	for _, member := range members {
+		if member.UserID != message.SenderID && member.LastReadAt.Valid && !member.LastReadAt.Time.Before(message.CreatedAt) {
+			messageResponse.ReadBy = append(messageResponse.ReadBy, member.UserID)
+		}
+	}
+	return messageResponse
+}
+
+func validateDirectMessageBody(body string) error {
+	if body == "" {
+		return errors.New("body is required")
+	}
+	if length := graphemes.Count(body); length > maxDirectMessageLength {
+		return chirpTooLongError{Length: length, Limit: maxDirectMessageLength}
+	}
+	return nil
+}
+
+// memberConversation loads a conversation for one of its members. Anyone
+// else gets sql.ErrNoRows, so conversations they are not in look missing.
+func (cfg *apiConfig) memberConversation(ctx context.Context, r *http.Request, userID uuid.UUID) (database.Conversation, error) {
+	conversationID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		return database.Conversation{}, sql.ErrNoRows
+	}
+	params := database.GetMemberConversationParams{
+		ID:     conversationID,
+		UserID: userID,
+	}
+	return cfg.dbQueries.GetMemberConversation(ctx, params)
+}
+
+func (cfg *apiConfig) handlerCreateConversation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if !cfg.requireVerifiedEmail(w, r, UserID) {
+		return
+	}
+
+	type Request struct {
+		MemberIDs []uuid.UUID `json:"member_ids"`
+	}
+	var req Request
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body format")
+		return
+	}
+
+	memberIDs := []uuid.UUID{UserID}
+	for _, memberID := range req.MemberIDs {
+		if !slices.Contains(memberIDs, memberID) {
+			memberIDs = append(memberIDs, memberID)
+		}
+	}
+	if len(memberIDs) < 2 {
+		respondWithError(w, http.StatusBadRequest, "A conversation needs at least one other member")
+		return
+	}
+	if len(memberIDs) > maxConversationMembers {
+		respondWithError(w, http.StatusBadRequest, "A conversation can have at most 50 members")
+		return
+	}
+	for _, memberID := range memberIDs[1:] {
+		if _, err := cfg.dbQueries.GetUser(r.Context(), memberID); err != nil {
+			respondWithError(w, http.StatusBadRequest, "User "+memberID.String()+" not found")
+			return
+		}
+	}
+	blocked, err := cfg.isBlockedBetween(r.Context(), UserID, memberIDs[1:]...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "You can't message a user you have blocked or who has blocked you")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	conversation, err := qtx.CreateConversation(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	membersParams := database.AddConversationMembersParams{
+		ConversationID: conversation.ID,
+		UserIds:        memberIDs,
+	}
+	if err := qtx.AddConversationMembers(r.Context(), membersParams); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	members, err := qtx.GetConversationMembers(r.Context(), []uuid.UUID{conversation.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newConversationResponse(conversation, members, 0))
+}
+
+// handlerGetConversations lists the caller's conversations, most recently
+// active first.
+func (cfg *apiConfig) handlerGetConversations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetUserConversationsParams{
+		UserID: UserID,
+		Limit:  limit + 1,
+	}
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil || cursor.Direction != cursorNext {
+			respondWithError(w, http.StatusBadRequest, "cursor is invalid")
+			return
+		}
+		params.BeforeActivityAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.dbQueries.GetUserConversations(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	hasMore := len(rows) > int(limit)
+	if hasMore {
+		rows = rows[:limit]
+	}
+
+	conversationIDs := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		conversationIDs[i] = row.Conversation.ID
+	}
+	members, err := cfg.dbQueries.GetConversationMembers(r.Context(), conversationIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	membersByConversationID := make(map[uuid.UUID][]database.ConversationMember)
+	for _, member := range members {
+		membersByConversationID[member.ConversationID] = append(membersByConversationID[member.ConversationID], member)
+	}
+
+	page := ConversationsPageResponse{Conversations: make([]ConversationResponse, len(rows))}
+	for i, row := range rows {
+		page.Conversations[i] = newConversationResponse(row.Conversation, membersByConversationID[row.Conversation.ID], row.UnreadCount)
+	}
+	if hasMore {
+		last := rows[len(rows)-1].Conversation
+		page.NextCursor = encodeCursor(cursorNext, last.LastActivityAt, last.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func (cfg *apiConfig) handlerGetConversation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	conversation, err := cfg.memberConversation(r.Context(), r, UserID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	members, err := cfg.dbQueries.GetConversationMembers(r.Context(), []uuid.UUID{conversation.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	unreadParams := database.CountUnreadMessagesParams{
+		UserID:         UserID,
+		ConversationID: uuid.NullUUID{UUID: conversation.ID, Valid: true},
+	}
+	unreadCount, err := cfg.dbQueries.CountUnreadMessages(r.Context(), unreadParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newConversationResponse(conversation, members, unreadCount))
+}
+
+func (cfg *apiConfig) handlerGetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	unreadCount, err := cfg.dbQueries.CountUnreadMessages(r.Context(), database.CountUnreadMessagesParams{UserID: UserID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, UnreadCountResponse{UnreadCount: unreadCount})
+}
+
+func (cfg *apiConfig) handlerSendDirectMessage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if !cfg.requireVerifiedEmail(w, r, UserID) {
+		return
+	}
+
+	type Request struct {
+		Body string `json:"body"`
+	}
+	var req Request
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body format")
+		return
+	}
+	if err := validateDirectMessageBody(req.Body); err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	conversation, err := cfg.memberConversation(r.Context(), r, UserID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	currentMembers, err := cfg.dbQueries.GetConversationMembers(r.Context(), []uuid.UUID{conversation.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var otherMemberIDs []uuid.UUID
+	for _, member := range currentMembers {
+		if member.UserID != UserID {
+			otherMemberIDs = append(otherMemberIDs, member.UserID)
+		}
+	}
+	blocked, err := cfg.isBlockedBetween(r.Context(), UserID, otherMemberIDs...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "You can't message a user you have blocked or who has blocked you")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	messageParams := database.CreateDirectMessageParams{
+		ConversationID: conversation.ID,
+		SenderID:       UserID,
+		Body:           req.Body,
+	}
+	message, err := qtx.CreateDirectMessage(r.Context(), messageParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	touchParams := database.TouchConversationParams{
+		ID:             conversation.ID,
+		LastActivityAt: message.CreatedAt,
+	}
+	if err := qtx.TouchConversation(r.Context(), touchParams); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senders have read everything up to their own message.
+	readParams := database.MarkConversationReadParams{
+		ReadAt:         message.CreatedAt,
+		ConversationID: conversation.ID,
+		UserID:         UserID,
+	}
+	if err := qtx.MarkConversationRead(r.Context(), readParams); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	members, err := qtx.GetConversationMembers(r.Context(), []uuid.UUID{conversation.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newDirectMessageResponse(message, members))
+}
+
+// handlerGetDirectMessages lists a conversation's messages newest first.
+// Deleted messages stay in place with an empty body.
+func (cfg *apiConfig) handlerGetDirectMessages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	conversation, err := cfg.memberConversation(r.Context(), r, UserID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetDirectMessagesParams{
+		ConversationID: conversation.ID,
+		Limit:          limit + 1,
+	}
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil || cursor.Direction != cursorNext {
+			respondWithError(w, http.StatusBadRequest, "cursor is invalid")
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	messages, err := cfg.dbQueries.GetDirectMessages(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	members, err := cfg.dbQueries.GetConversationMembers(r.Context(), []uuid.UUID{conversation.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	hasMore := len(messages) > int(limit)
+	if hasMore {
+		messages = messages[:limit]
+	}
+
+	page := DirectMessagesPageResponse{Messages: make([]DirectMessageResponse, len(messages))}
+	for i, message := range messages {
+		page.Messages[i] = newDirectMessageResponse(message, members)
+	}
+	if hasMore {
+		last := messages[len(messages)-1]
+		page.NextCursor = encodeCursor(cursorNext, last.CreatedAt, last.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func (cfg *apiConfig) handlerUpdateDirectMessage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	messageID, err := uuid.Parse(r.PathValue("messageID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	type Request struct {
+		Body string `json:"body"`
+	}
+	var req Request
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body format")
+		return
+	}
+	if err := validateDirectMessageBody(req.Body); err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	conversation, err := cfg.memberConversation(r.Context(), r, UserID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	getParams := database.GetDirectMessageForUpdateParams{
+		ID:             messageID,
+		ConversationID: conversation.ID,
+	}
+	message, err := qtx.GetDirectMessageForUpdate(r.Context(), getParams)
+	if err != nil || message.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Message not found")
+		return
+	}
+	if message.SenderID != UserID {
+		respondWithError(w, http.StatusForbidden, "You can only edit your own messages")
+		return
+	}
+
+	updateParams := database.UpdateDirectMessageParams{
+		ID:   message.ID,
+		Body: req.Body,
+	}
+	updatedMessage, err := qtx.UpdateDirectMessage(r.Context(), updateParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	members, err := qtx.GetConversationMembers(r.Context(), []uuid.UUID{conversation.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newDirectMessageResponse(updatedMessage, members))
+}
+
+func (cfg *apiConfig) handlerDeleteDirectMessage(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	messageID, err := uuid.Parse(r.PathValue("messageID"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	conversation, err := cfg.memberConversation(r.Context(), r, UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	getParams := database.GetDirectMessageForUpdateParams{
+		ID:             messageID,
+		ConversationID: conversation.ID,
+	}
+	message, err := qtx.GetDirectMessageForUpdate(r.Context(), getParams)
+	if err != nil || message.DeletedAt.Valid {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if message.SenderID != UserID {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if err := qtx.DeleteDirectMessage(r.Context(), message.ID); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerMarkConversationRead records that the caller has read the
+// conversation up to message_id, or up to now without one. Read positions
+// only move forward.
+func (cfg *apiConfig) handlerMarkConversationRead(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	type Request struct {
+		MessageID *uuid.UUID `json:"message_id"`
+	}
+	var req Request
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body format")
+		return
+	}
+
+	conversation, err := cfg.memberConversation(r.Context(), r, UserID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	readAt := time.Now().UTC()
+	if req.MessageID != nil {
+		getParams := database.GetDirectMessageForUpdateParams{
+			ID:             *req.MessageID,
+			ConversationID: conversation.ID,
+		}
+		message, err := cfg.dbQueries.GetDirectMessageForUpdate(r.Context(), getParams)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Message not found")
+			return
+		}
+		readAt = message.CreatedAt
+	}
+
+	readParams := database.MarkConversationReadParams{
+		ReadAt:         readAt,
+		ConversationID: conversation.ID,
+		UserID:         UserID,
+	}
+	if err := cfg.dbQueries.MarkConversationRead(r.Context(), readParams); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
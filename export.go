@@ -0,0 +1,494 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/exy63/chirpy/internal/auth"
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	exportVersion      = 1
+	exportBatchSize    = 500
+	exportArchiveEntry = "chirpy-export.jsonl"
+	maxImportBytes     = 32 << 20
+	maxImportLineBytes = 1 << 20
+)
+
+const (
+	exportRecordExport  = "export"
+	exportRecordProfile = "profile"
+	exportRecordChirp   = "chirp"
+	exportRecordSession = "session"
+)
+
+// ExportRecord is one line of an export. The first line describes the
+// export itself, followed by the profile, the chirps oldest first and the
+// sessions.
+type ExportRecord struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+type ExportHeader struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+type ExportedChirp struct {
+	ID               uuid.UUID       `json:"id"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+	Body             string          `json:"body"`
+	OriginalBody     *string         `json:"original_body,omitempty"`
+	EditedAt         *time.Time      `json:"edited_at,omitempty"`
+	ParentID         *uuid.UUID      `json:"parent_id,omitempty"`
+	RechirpOfID      *uuid.UUID      `json:"rechirp_of_id,omitempty"`
+	QuoteOfID        *uuid.UUID      `json:"quote_of_id,omitempty"`
+	Published        bool            `json:"published"`
+	PublishAt        *time.Time      `json:"publish_at,omitempty"`
+	ModerationStatus string          `json:"moderation_status"`
+	Media            []MediaResponse `json:"media,omitempty"`
+}
+
+// ExportedSession describes a refresh token without the token itself.
+type ExportedSession struct {
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ImportResponse struct {
+	Imported int           `json:"imported"`
+	Skipped  int           `json:"skipped"`
+	Errors   []ImportError `json:"errors"`
+}
+
+func newExportedChirp(chirp database.Chirp) ExportedChirp {
+	exported := ExportedChirp{
+		ID:               chirp.ID,
+		CreatedAt:        chirp.CreatedAt,
+		UpdatedAt:        chirp.UpdatedAt,
+		Body:             chirp.Body,
+		Published:        chirp.Published,
+		ModerationStatus: chirp.ModerationStatus,
+	}
+	if chirp.OriginalBody.Valid {
+		exported.OriginalBody = &chirp.OriginalBody.String
+	}
+	if chirp.EditedAt.Valid {
+		exported.EditedAt = &chirp.EditedAt.Time
+	}
+	if chirp.ParentID.Valid {
+		exported.ParentID = &chirp.ParentID.UUID
+	}
+	if chirp.RechirpOfID.Valid {
+		exported.RechirpOfID = &chirp.RechirpOfID.UUID
+	}
+	if chirp.QuoteOfID.Valid {
+		exported.QuoteOfID = &chirp.QuoteOfID.UUID
+	}
+	if chirp.PublishAt.Valid {
+		exported.PublishAt = &chirp.PublishAt.Time
+	}
+	return exported
+}
+
+// handlerExportUser streams everything Chirpy holds about the caller as
+// JSON Lines, or with format=zip as a zip archive holding the same file.
+func (cfg *apiConfig) handlerExportUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "jsonl" && format != "zip" {
+		respondWithError(w, http.StatusBadRequest, "format must be jsonl or zip")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUser(r.Context(), UserID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	filename := "chirpy-export-" + time.Now().UTC().Format("20060102")
+	if format == "zip" {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+
+		archive := zip.NewWriter(w)
+		entry, err := archive.Create(exportArchiveEntry)
+		if err == nil {
+			err = cfg.writeExport(r.Context(), entry, user)
+		}
+		if err == nil {
+			err = archive.Close()
+		}
+		if err != nil {
+			log.Printf("Couldn't export user %s: %v", UserID, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.jsonl"`)
+	if err := cfg.writeExport(r.Context(), w, user); err != nil {
+		log.Printf("Couldn't export user %s: %v", UserID, err)
+	}
+}
+
+// writeExport writes the export one record per line, fetching chirps in
+// batches so large accounts never sit in memory at once. Once the first
+// line is out the status code is sent, so failures can only cut the
+// export short.
+func (cfg *apiConfig) writeExport(ctx context.Context, w io.Writer, user database.User) error {
+	encoder := json.NewEncoder(w)
+
+	header := ExportHeader{Version: exportVersion, ExportedAt: time.Now().UTC()}
+	if err := encoder.Encode(ExportRecord{Type: exportRecordExport, Data: header}); err != nil {
+		return err
+	}
+
+	profile, err := cfg.newUserResponse(ctx, user)
+	if err != nil {
+		return err
+	}
+	if err := encoder.Encode(ExportRecord{Type: exportRecordProfile, Data: profile}); err != nil {
+		return err
+	}
+
+	params := database.GetUserChirpsForExportParams{
+		UserID: user.ID,
+		Limit:  exportBatchSize,
+	}
+	for {
+		chirps, err := cfg.dbQueries.GetUserChirpsForExport(ctx, params)
+		if err != nil {
+			return err
+		}
+		if len(chirps) == 0 {
+			break
+		}
+
+		chirpIDs := make([]uuid.UUID, len(chirps))
+		for i, chirp := range chirps {
+			chirpIDs[i] = chirp.ID
+		}
+		attachments, err := cfg.dbQueries.GetMediaForChirps(ctx, chirpIDs)
+		if err != nil {
+			return err
+		}
+		mediaByChirpID := make(map[uuid.UUID][]MediaResponse)
+		for _, attachment := range attachments {
+			mediaByChirpID[attachment.ChirpID.UUID] = append(mediaByChirpID[attachment.ChirpID.UUID], cfg.newMediaResponse(attachment))
+		}
+
+		for _, chirp := range chirps {
+			exported := newExportedChirp(chirp)
+			exported.Media = mediaByChirpID[chirp.ID]
+			if err := encoder.Encode(ExportRecord{Type: exportRecordChirp, Data: exported}); err != nil {
+				return err
+			}
+		}
+
+		if len(chirps) < exportBatchSize {
+			break
+		}
+		last := chirps[len(chirps)-1]
+		params.AfterCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: last.ID, Valid: true}
+	}
+
+	tokens, err := cfg.dbQueries.GetUserRefreshTokens(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		session := ExportedSession{
+			CreatedAt: token.CreatedAt,
+			UpdatedAt: token.UpdatedAt,
+			ExpiresAt: token.ExpiresAt,
+		}
+		if token.RevokedAt.Valid {
+			session.RevokedAt = &token.RevokedAt.Time
+		}
+		if err := encoder.Encode(ExportRecord{Type: exportRecordSession, Data: session}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// handlerImportUser recreates the chirps of an export, as JSON Lines or as
+// the zip archive handlerExportUser produces, for the caller. Every chirp
+// keeps its timestamps, as long as none lies in the future, but goes
+// through getCleanedBody again. Lines fail
+// on their own: the response lists each failed line and why.
+func (cfg *apiConfig) handlerImportUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if !cfg.requireVerifiedEmail(w, r, UserID) {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "Import is too large")
+		return
+	}
+
+	lines, err := openImport(body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	policy, err := cfg.chirpPolicy(r.Context(), UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	res := ImportResponse{Errors: []ImportError{}}
+	// importedIDs maps chirp IDs in the export to the chirps created for
+	// them, so replies, rechirps and quotes of imported chirps stay linked.
+	importedIDs := make(map[uuid.UUID]uuid.UUID)
+
+	scanner := bufio.NewScanner(lines)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineBytes)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		type Record struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			res.Errors = append(res.Errors, ImportError{Line: line, Error: "Invalid JSON"})
+			continue
+		}
+		if record.Type != exportRecordChirp {
+			res.Skipped++
+			continue
+		}
+
+		var exported ExportedChirp
+		if err := json.Unmarshal(record.Data, &exported); err != nil {
+			res.Errors = append(res.Errors, ImportError{Line: line, Error: "Invalid chirp"})
+			continue
+		}
+
+		chirp, err := cfg.importChirp(r.Context(), UserID, policy, exported, importedIDs)
+		if err != nil {
+			res.Errors = append(res.Errors, ImportError{Line: line, Error: err.Error()})
+			continue
+		}
+		importedIDs[exported.ID] = chirp.ID
+		res.Imported++
+	}
+	if err := scanner.Err(); err != nil {
+		res.Errors = append(res.Errors, ImportError{Error: "Couldn't read the rest of the import: " + err.Error()})
+	}
+
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+// openImport accepts an export either as JSON Lines or as a zip archive
+// holding them.
+func openImport(body []byte) (io.Reader, error) {
+	if !bytes.HasPrefix(body, []byte("PK\x03\x04")) {
+		return bytes.NewReader(body), nil
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, errors.New("Invalid zip archive")
+	}
+	entry, err := archive.Open(exportArchiveEntry)
+	if err != nil {
+		return nil, errors.New("Zip archive has no " + exportArchiveEntry)
+	}
+	return entry, nil
+}
+
+// validateImportedTimestamps stops an import from dating chirps in the
+// future, where they would stay on top of every listing. A future
+// publish_at is the one exception: it schedules the chirp, within the same
+// limit as scheduling one directly.
+func validateImportedTimestamps(exported ExportedChirp, now time.Time) error {
+	if exported.CreatedAt.After(now) {
+		return errors.New("created_at can't be in the future")
+	}
+	if exported.UpdatedAt.After(now) {
+		return errors.New("updated_at can't be in the future")
+	}
+	if !exported.UpdatedAt.IsZero() && exported.UpdatedAt.Before(exported.CreatedAt) {
+		return errors.New("updated_at can't be before created_at")
+	}
+	if exported.EditedAt != nil && exported.EditedAt.After(now) {
+		return errors.New("edited_at can't be in the future")
+	}
+	if exported.PublishAt != nil && exported.PublishAt.After(now.Add(maxScheduleAhead)) {
+		return errors.New("publish_at must be within a year")
+	}
+	return nil
+}
+
+func (cfg *apiConfig) importChirp(ctx context.Context, userID uuid.UUID, policy chirpPolicy, exported ExportedChirp, importedIDs map[uuid.UUID]uuid.UUID) (database.Chirp, error) {
+	if exported.CreatedAt.IsZero() {
+		return database.Chirp{}, errors.New("created_at is required")
+	}
+	if err := validateImportedTimestamps(exported, time.Now()); err != nil {
+		return database.Chirp{}, err
+	}
+
+	// resolve finds the chirp an exported rechirp or quote points to: one
+	// created earlier in this import, or one that already exists here.
+	resolve := func(id *uuid.UUID, kind string) (uuid.NullUUID, error) {
+		if id == nil {
+			return uuid.NullUUID{}, nil
+		}
+		if importedID, ok := importedIDs[*id]; ok {
+			return uuid.NullUUID{UUID: importedID, Valid: true}, nil
+		}
+		chirp, err := cfg.resolveSharedChirp(ctx, *id)
+		if err != nil {
+			return uuid.NullUUID{}, fmt.Errorf("%s chirp %s not found", kind, id)
+		}
+		return uuid.NullUUID{UUID: chirp.ID, Valid: true}, nil
+	}
+
+	parentID := uuid.NullUUID{}
+	if exported.ParentID != nil {
+		if importedID, ok := importedIDs[*exported.ParentID]; ok {
+			parentID = uuid.NullUUID{UUID: importedID, Valid: true}
+		} else {
+			parent, err := cfg.dbQueries.GetChirp(ctx, *exported.ParentID)
+			if err != nil || parent.DeletedAt.Valid || !parent.Published {
+				return database.Chirp{}, fmt.Errorf("Parent chirp %s not found", exported.ParentID)
+			}
+			blocked, err := cfg.isBlockedBetween(ctx, userID, parent.UserID)
+			if err != nil {
+				return database.Chirp{}, err
+			}
+			if blocked {
+				return database.Chirp{}, fmt.Errorf("Parent chirp %s is by a user you have blocked or who has blocked you", exported.ParentID)
+			}
+			parentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		}
+	}
+	rechirpOfID, err := resolve(exported.RechirpOfID, "Rechirped")
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	quoteOfID, err := resolve(exported.QuoteOfID, "Quoted")
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	cleaned := cleanedBody{ModerationStatus: moderationApproved}
+	if !rechirpOfID.Valid {
+		// The original body is what the author wrote; masking is redone
+		// with this server's rules.
+		body := exported.Body
+		if exported.OriginalBody != nil {
+			body = *exported.OriginalBody
+		}
+		cleaned, err = getCleanedBody(body, policy)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	publishAt := sql.NullTime{}
+	if exported.PublishAt != nil {
+		publishAt = sql.NullTime{Time: exported.PublishAt.UTC(), Valid: true}
+	}
+	editedAt := sql.NullTime{}
+	if exported.EditedAt != nil {
+		editedAt = sql.NullTime{Time: exported.EditedAt.UTC(), Valid: true}
+	}
+	updatedAt := exported.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = exported.CreatedAt
+	}
+
+	params := database.ImportChirpParams{
+		CreatedAt:        exported.CreatedAt.UTC(),
+		UpdatedAt:        updatedAt.UTC(),
+		Body:             cleaned.Body,
+		UserID:           userID,
+		ParentID:         parentID,
+		RechirpOfID:      rechirpOfID,
+		QuoteOfID:        quoteOfID,
+		Published:        cleaned.ModerationStatus == moderationApproved && (!publishAt.Valid || !publishAt.Time.After(time.Now())),
+		PublishAt:        publishAt,
+		OriginalBody:     cleaned.OriginalBody,
+		ModerationStatus: cleaned.ModerationStatus,
+		EditedAt:         editedAt,
+	}
+
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := qtx.ImportChirp(ctx, params)
+	if err != nil {
+		return database.Chirp{}, errors.New("Couldn't create chirp")
+	}
+	if chirp.Published {
+		if err := syncChirpHashtags(ctx, qtx, chirp); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	return chirp, tx.Commit()
+}
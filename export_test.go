@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateImportedTimestamps(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Minute)
+	farFuture := now.Add(2 * maxScheduleAhead)
+
+	tests := []struct {
+		name     string
+		exported ExportedChirp
+		wantErr  string
+	}{
+		{name: "all in the past", exported: ExportedChirp{CreatedAt: past, UpdatedAt: now, EditedAt: &now, PublishAt: &past}},
+		{name: "no updated_at", exported: ExportedChirp{CreatedAt: past}},
+		{name: "scheduled", exported: ExportedChirp{CreatedAt: past, UpdatedAt: past, PublishAt: &future}},
+		{name: "future created_at", exported: ExportedChirp{CreatedAt: future, UpdatedAt: future}, wantErr: "created_at can't be in the future"},
+		{name: "future updated_at", exported: ExportedChirp{CreatedAt: past, UpdatedAt: future}, wantErr: "updated_at can't be in the future"},
+		{name: "updated before created", exported: ExportedChirp{CreatedAt: now, UpdatedAt: past}, wantErr: "updated_at can't be before created_at"},
+		{name: "future edited_at", exported: ExportedChirp{CreatedAt: past, UpdatedAt: past, EditedAt: &future}, wantErr: "edited_at can't be in the future"},
+		{name: "publish_at too far ahead", exported: ExportedChirp{CreatedAt: past, UpdatedAt: past, PublishAt: &farFuture}, wantErr: "publish_at must be within a year"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateImportedTimestamps(tt.exported, now)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"time"
+
+	"github.com/exy63/chirpy/internal/graphemes"
+	"github.com/google/uuid"
+)
+
+const (
+	atomContentType = "application/atom+xml; charset=utf-8"
+	rssContentType  = "application/rss+xml; charset=utf-8"
+	// feedTitleLength is how many characters of a chirp make its entry
+	// title; feed readers show titles in narrow lists.
+	feedTitleLength = 60
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Link      atomLink    `xml:"link"`
+	Author    atomAuthor  `xml:"author"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	GUID        rssGUID `xml:"guid"`
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	ID          string `xml:",chardata"`
+}
+
+// feed is what both formats are rendered from: the newest chirps of the
+// global timeline or of one author, as GET /api/chirps would list them.
+type feed struct {
+	BaseURL      string
+	ID           string
+	Title        string
+	Link         string
+	SelfLink     string
+	Chirps       []ChirpResponse
+	LastModified time.Time
+}
+
+func (cfg *apiConfig) handlerGetChirpsAtomFeed(w http.ResponseWriter, r *http.Request) {
+	cfg.serveFeed(w, r, uuid.NullUUID{}, renderAtomFeed, atomContentType)
+}
+
+func (cfg *apiConfig) handlerGetChirpsRSSFeed(w http.ResponseWriter, r *http.Request) {
+	cfg.serveFeed(w, r, uuid.NullUUID{}, renderRSSFeed, rssContentType)
+}
+
+func (cfg *apiConfig) handlerGetUserAtomFeed(w http.ResponseWriter, r *http.Request) {
+	cfg.serveUserFeed(w, r, renderAtomFeed, atomContentType)
+}
+
+func (cfg *apiConfig) handlerGetUserRSSFeed(w http.ResponseWriter, r *http.Request) {
+	cfg.serveUserFeed(w, r, renderRSSFeed, rssContentType)
+}
+
+func (cfg *apiConfig) serveUserFeed(w http.ResponseWriter, r *http.Request, render func(feed) ([]byte, error), contentType string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := r.PathValue("id")
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := cfg.dbQueries.GetUser(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	cfg.serveFeed(w, r, uuid.NullUUID{UUID: userID, Valid: true}, render, contentType)
+}
+
+func (cfg *apiConfig) serveFeed(w http.ResponseWriter, r *http.Request, authorID uuid.NullUUID, render func(feed) ([]byte, error), contentType string) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	limit, err := parsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f, err := cfg.buildFeed(r.Context(), cfg.publicBaseURL, r.URL.Path, authorID, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	body, err := render(f)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// A chirp dropping out of the feed leaves the newest updated_at as it
+	// was, so the feed is validated by ETag only, like the chirp listings.
+	respondWithCacheable(w, r, uuid.NullUUID{}, contentType, body, time.Time{})
+}
+
+func (cfg *apiConfig) buildFeed(ctx context.Context, baseURL string, path string, authorID uuid.NullUUID, limit int32) (feed, error) {
+	filter := chirpFilter{}
+	f := feed{
+		BaseURL:  baseURL,
+		ID:       baseURL + "/api/chirps",
+		Title:    "Chirpy",
+		Link:     baseURL + "/api/chirps",
+		SelfLink: baseURL + path,
+	}
+	if authorID.Valid {
+		filter.AuthorIDs = []uuid.UUID{authorID.UUID}
+		f.ID = "urn:uuid:" + authorID.UUID.String()
+		f.Title = "Chirps by " + authorID.UUID.String()
+		f.Link = baseURL + "/api/chirps?author_id=" + authorID.UUID.String()
+	}
+
+	chirps, _, err := cfg.getChirpsPage(ctx, filter, true, nil, limit)
+	if err != nil {
+		return feed{}, err
+	}
+
+	f.Chirps = make([]ChirpResponse, len(chirps))
+	chirpResponses := make([]*ChirpResponse, len(chirps))
+	for i, chirp := range chirps {
+		f.Chirps[i] = newChirpResponse(chirp)
+		chirpResponses[i] = &f.Chirps[i]
+		if chirp.UpdatedAt.After(f.LastModified) {
+			f.LastModified = chirp.UpdatedAt
+		}
+	}
+	if err := cfg.enrichChirps(ctx, uuid.NullUUID{}, chirpResponses); err != nil {
+		return feed{}, err
+	}
+
+	return f, nil
+}
+
+// feedEntryText is what a feed shows for a chirp. Rechirps have no body of
+// their own, so they show the chirp they share.
+func feedEntryText(chirp ChirpResponse) string {
+	if chirp.RechirpOf != nil {
+		return "Rechirped: " + chirp.RechirpOf.Body
+	}
+	if chirp.RechirpOfID != nil {
+		return "Rechirped a chirp that is no longer available"
+	}
+	return chirp.Body
+}
+
+func feedEntryTitle(text string) string {
+	title := graphemes.Prefix(text, feedTitleLength)
+	if len(title) < len(text) {
+		return title + "…"
+	}
+	return title
+}
+
+func renderAtomFeed(f feed) ([]byte, error) {
+	atom := atomFeed{
+		ID:      f.ID,
+		Title:   f.Title,
+		Updated: f.LastModified.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.SelfLink, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.Link, Rel: "alternate", Type: "application/json"},
+		},
+		Entries: make([]atomEntry, len(f.Chirps)),
+	}
+	if f.LastModified.IsZero() {
+		atom.Updated = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	for i, chirp := range f.Chirps {
+		text := feedEntryText(chirp)
+		atom.Entries[i] = atomEntry{
+			ID:        "urn:uuid:" + chirp.ID.String(),
+			Title:     feedEntryTitle(text),
+			Updated:   chirp.UpdatedAt.UTC().Format(time.RFC3339),
+			Published: chirp.CreatedAt.UTC().Format(time.RFC3339),
+			Link:      atomLink{Href: f.BaseURL + "/api/chirps/" + chirp.ID.String(), Rel: "alternate", Type: "application/json"},
+			Author:    atomAuthor{Name: chirp.UserID, URI: f.BaseURL + "/api/users/" + chirp.UserID + "/feed.atom"},
+			Content:   atomContent{Type: "text", Body: text},
+		}
+	}
+
+	body, err := xml.MarshalIndent(atom, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+func renderRSSFeed(f feed) ([]byte, error) {
+	rss := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.Link,
+			Description: f.Title + " on Chirpy",
+			Items:       make([]rssItem, len(f.Chirps)),
+		},
+	}
+	if !f.LastModified.IsZero() {
+		rss.Channel.LastBuildDate = f.LastModified.UTC().Format(time.RFC1123Z)
+	}
+
+	for i, chirp := range f.Chirps {
+		text := feedEntryText(chirp)
+		rss.Channel.Items[i] = rssItem{
+			GUID:        rssGUID{IsPermaLink: false, ID: "urn:uuid:" + chirp.ID.String()},
+			Title:       feedEntryTitle(text),
+			Link:        f.BaseURL + "/api/chirps/" + chirp.ID.String(),
+			Description: text,
+			PubDate:     chirp.CreatedAt.UTC().Format(time.RFC1123Z),
+		}
+	}
+
+	body, err := xml.MarshalIndent(rss, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/exy63/chirpy/internal/auth"
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type FollowResponse struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowsPageResponse struct {
+	Users      []FollowResponse `json:"users"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if !cfg.requireVerifiedEmail(w, r, UserID) {
+		return
+	}
+
+	id := r.PathValue("id")
+	followeeID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if followeeID == UserID {
+		respondWithError(w, http.StatusBadRequest, "You can't follow yourself")
+		return
+	}
+
+	if _, err := cfg.dbQueries.GetUser(r.Context(), followeeID); err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	blocked, err := cfg.isBlockedBetween(r.Context(), UserID, followeeID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "You can't follow a user you have blocked or who has blocked you")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	params := database.FollowUserParams{
+		FollowerID: UserID,
+		FolloweeID: followeeID,
+	}
+	followed, err := qtx.FollowUser(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if followed == 0 {
+		respondWithError(w, http.StatusConflict, "You already follow this user")
+		return
+	}
+
+	if err := notify(r.Context(), qtx, followeeID, notificationFollow, uuid.NullUUID{UUID: UserID, Valid: true}, uuid.NullUUID{}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id := r.PathValue("id")
+	followeeID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.UnfollowUserParams{
+		FollowerID: UserID,
+		FolloweeID: followeeID,
+	}
+	unfollowed, err := cfg.dbQueries.UnfollowUser(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if unfollowed == 0 {
+		respondWithError(w, http.StatusNotFound, "You don't follow this user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	cfg.serveFollows(w, r, false)
+}
+
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	cfg.serveFollows(w, r, true)
+}
+
+// serveFollows pages through the users who follow {id}, or the users {id}
+// follows, most recent follow first.
+func (cfg *apiConfig) serveFollows(w http.ResponseWriter, r *http.Request, following bool) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	id := r.PathValue("id")
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, err := cfg.dbQueries.GetUser(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	params := database.GetFollowersParams{
+		UserID: userID,
+		Limit:  limit + 1,
+	}
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil || cursor.Direction != cursorNext {
+			respondWithError(w, http.StatusBadRequest, "cursor is invalid")
+			return
+		}
+		params.BeforeFollowedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeUserID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	var follows []FollowResponse
+	if following {
+		rows, err := cfg.dbQueries.GetFollowing(r.Context(), database.GetFollowingParams(params))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, row := range rows {
+			follows = append(follows, FollowResponse{UserID: row.UserID, FollowedAt: row.FollowedAt})
+		}
+	} else {
+		rows, err := cfg.dbQueries.GetFollowers(r.Context(), params)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, row := range rows {
+			follows = append(follows, FollowResponse{UserID: row.UserID, FollowedAt: row.FollowedAt})
+		}
+	}
+
+	hasMore := len(follows) > int(limit)
+	if hasMore {
+		follows = follows[:limit]
+	}
+
+	page := FollowsPageResponse{Users: follows}
+	if page.Users == nil {
+		page.Users = []FollowResponse{}
+	}
+	if hasMore {
+		last := follows[len(follows)-1]
+		page.NextCursor = encodeCursor(cursorNext, last.FollowedAt, last.UserID)
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/exy63/chirpy/internal/hashtags"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTrendingWindow = time.Hour
+	maxTrendingWindow     = 7 * 24 * time.Hour
+)
+
+type TrendingHashtagResponse struct {
+	Tag           string  `json:"tag"`
+	RecentCount   int64   `json:"recent_count"`
+	PreviousCount int64   `json:"previous_count"`
+	Velocity      float64 `json:"velocity"`
+}
+
+// syncChirpHashtags replaces the stored hashtags of a chirp with the ones in
+// its current body. Tags keep the chirp's creation time so tag pages list
+// chirps in the same order as the main timeline.
+func syncChirpHashtags(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := q.DeleteChirpHashtags(ctx, chirp.ID); err != nil {
+		return err
+	}
+
+	tags := hashtags.Extract(chirp.Body)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	params := database.AddChirpHashtagsParams{
+		ChirpID:   chirp.ID,
+		Tags:      tags,
+		CreatedAt: chirp.CreatedAt,
+	}
+	return q.AddChirpHashtags(ctx, params)
+}
+
+func (cfg *apiConfig) handlerGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	tag, err := hashtags.Normalize(r.PathValue("tag"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	params := database.GetHashtagChirpsParams{
+		Tag:      tag,
+		Limit:    limit + 1,
+		ViewerID: viewerID,
+	}
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil || cursor.Direction != cursorNext {
+			respondWithError(w, http.StatusBadRequest, "cursor is invalid")
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeChirpID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.dbQueries.GetHashtagChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	hasMore := len(rows) > int(limit)
+	if hasMore {
+		rows = rows[:limit]
+	}
+
+	page := ChirpsPageResponse{Chirps: make([]ChirpResponse, len(rows))}
+	chirpResponses := make([]*ChirpResponse, len(rows))
+	for i, row := range rows {
+		page.Chirps[i] = newChirpResponse(row.Chirp)
+		chirpResponses[i] = &page.Chirps[i]
+	}
+	if err := cfg.enrichChirps(r.Context(), viewerID, chirpResponses); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if hasMore {
+		last := rows[len(rows)-1].Chirp
+		page.NextCursor = encodeCursor(cursorNext, last.CreatedAt, last.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// handlerGetTrendingHashtags ranks tags by velocity: how many more uses a tag
+// had in the latest window than in the window before it, per hour.
+func (cfg *apiConfig) handlerGetTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	window := defaultTrendingWindow
+	if rawWindow := query.Get("window"); rawWindow != "" {
+		window, err = time.ParseDuration(rawWindow)
+		if err != nil || window < time.Minute || window > maxTrendingWindow {
+			respondWithError(w, http.StatusBadRequest, "window must be a duration between 1m and 168h")
+			return
+		}
+	}
+
+	params := database.GetTrendingHashtagsParams{
+		WindowSeconds: int32(window.Seconds()),
+		Limit:         limit,
+	}
+	rows, err := cfg.dbQueries.GetTrendingHashtags(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	trending := make([]TrendingHashtagResponse, len(rows))
+	for i, row := range rows {
+		velocity := float64(row.RecentCount-row.PreviousCount) / window.Hours()
+		trending[i] = TrendingHashtagResponse{
+			Tag:           row.Tag,
+			RecentCount:   row.RecentCount,
+			PreviousCount: row.PreviousCount,
+			Velocity:      math.Round(velocity*100) / 100,
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, trending)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/exy63/chirpy/internal/database"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotencyKeyTTL       = 24 * time.Hour
+	maxIdempotencyKeyLength = 255
+	idempotencyCleanupEvery = time.Hour
+	// maxIdempotentBodyBytes caps the request body read to hash it, which
+	// happens before the handler has authenticated anyone. The endpoints
+	// behind the middleware all take small JSON bodies.
+	maxIdempotentBodyBytes = 1 << 20
+)
+
+// idempotencyRecorder passes a response through to the client while keeping
+// a copy to replay for retries.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(statusCode int) {
+	if rec.statusCode == 0 {
+		rec.statusCode = statusCode
+	}
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.statusCode == 0 {
+		rec.statusCode = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// middlewareIdempotency makes a POST endpoint safe to retry. The first
+// request carrying an Idempotency-Key header runs as usual and its response
+// is stored; retries with the same key from the same caller within
+// idempotencyKeyTTL get that response replayed instead of running again.
+// Requests without the header are not affected.
+func (cfg *apiConfig) middlewareIdempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(key) > maxIdempotencyKeyLength {
+			respondWithError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request body format")
+			return
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		requestHash := sha256.Sum256(body)
+		claimParams := database.ClaimIdempotencyKeyParams{
+			Scope:       cfg.idempotencyScope(r),
+			Key:         key,
+			ExpiresAt:   time.Now().Add(idempotencyKeyTTL).UTC(),
+			RequestHash: hex.EncodeToString(requestHash[:]),
+		}
+		_, err = cfg.dbQueries.ClaimIdempotencyKey(r.Context(), claimParams)
+		if errors.Is(err, sql.ErrNoRows) {
+			cfg.replayIdempotentResponse(w, r, claimParams)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Del("Content-Type")
+		rec := &idempotencyRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		// Failures that a retry could get past are not worth remembering.
+		ctx := context.WithoutCancel(r.Context())
+		if rec.statusCode == 0 || rec.statusCode == http.StatusUnauthorized || rec.statusCode >= 500 {
+			releaseParams := database.ReleaseIdempotencyKeyParams{
+				Scope: claimParams.Scope,
+				Key:   key,
+			}
+			if err := cfg.dbQueries.ReleaseIdempotencyKey(ctx, releaseParams); err != nil {
+				log.Printf("Couldn't release idempotency key: %v", err)
+			}
+			return
+		}
+
+		saveParams := database.SaveIdempotentResponseParams{
+			Scope:        claimParams.Scope,
+			Key:          key,
+			StatusCode:   sql.NullInt32{Int32: int32(rec.statusCode), Valid: true},
+			ContentType:  rec.Header().Get("Content-Type"),
+			ResponseBody: rec.body.Bytes(),
+		}
+		if err := cfg.dbQueries.SaveIdempotentResponse(ctx, saveParams); err != nil {
+			log.Printf("Couldn't save idempotent response: %v", err)
+		}
+	})
+}
+
+// idempotencyScope keeps keys from different endpoints and different
+// callers apart. Callers are told apart by their user ID, so a retry still
+// matches after the client refreshes its access token.
+func (cfg *apiConfig) idempotencyScope(r *http.Request) string {
+	scope := r.Method + " " + r.URL.Path
+	if viewerID := cfg.viewerID(r); viewerID.Valid {
+		scope += " " + viewerID.UUID.String()
+	}
+	return scope
+}
+
+func (cfg *apiConfig) replayIdempotentResponse(w http.ResponseWriter, r *http.Request, claimParams database.ClaimIdempotencyKeyParams) {
+	getParams := database.GetIdempotencyKeyParams{
+		Scope: claimParams.Scope,
+		Key:   claimParams.Key,
+	}
+	stored, err := cfg.dbQueries.GetIdempotencyKey(r.Context(), getParams)
+	if err != nil {
+		// The key expired or was released between the claim and now.
+		respondWithError(w, http.StatusConflict, "Idempotency-Key is being processed, retry the request")
+		return
+	}
+
+	if stored.RequestHash != claimParams.RequestHash {
+		respondWithError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+		return
+	}
+	if !stored.StatusCode.Valid {
+		respondWithError(w, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+		return
+	}
+
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	} else {
+		w.Header().Del("Content-Type")
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(int(stored.StatusCode.Int32))
+	w.Write(stored.ResponseBody)
+}
+
+// runIdempotencyKeyCleanup deletes expired idempotency keys until ctx is
+// cancelled. Expired keys are already ignored when claimed, so this only
+// keeps the table from growing.
+func (cfg *apiConfig) runIdempotencyKeyCleanup(ctx context.Context) {
+	ticker := time.NewTicker(idempotencyCleanupEvery)
+	defer ticker.Stop()
+
+	for {
+		if _, err := cfg.dbQueries.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+			log.Printf("Couldn't delete expired idempotency keys: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
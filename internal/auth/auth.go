@@ -75,7 +75,7 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 
 func GetBearerToken(headers http.Header) (string, error) {
 	val := headers.Get("Authorization")
-	if val == "" {
+	if len(val) < len("Bearer ") {
 		return "", errors.New("authorization header value is invalid")
 	}
 	TOKEN_STRING := strings.Trim(val[len("Bearer "):], " ")
@@ -7,29 +7,106 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const approveHeldChirp = `-- name: ApproveHeldChirp :one
+UPDATE chirps
+SET moderation_status = 'approved',
+	published = publish_at IS NULL OR publish_at <= NOW(),
+	created_at = CASE WHEN publish_at <= NOW() THEN NOW() ELSE created_at END,
+	updated_at = NOW()
+WHERE id = $1 AND moderation_status = 'held'
+RETURNING id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status
+`
+
+func (q *Queries) ApproveHeldChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, approveHeldChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.BodyTsv,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.Published,
+		&i.PublishAt,
+		&i.OriginalBody,
+		&i.ModerationStatus,
+	)
+	return i, err
+}
+
+const countChirpReplies = `-- name: CountChirpReplies :one
+SELECT COUNT(*) FROM chirps
+WHERE parent_id = $1
+`
+
+func (q *Queries) CountChirpReplies(ctx context.Context, parentID uuid.NullUUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countChirpReplies, parentID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (
-    gen_random_uuid(),
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status)
+SELECT
+	new_chirp.id,
 	NOW(),
 	NOW(),
 	$1,
-	$2
-)
-RETURNING id, created_at, updated_at, body, user_id
+	$2,
+	$3::uuid,
+	COALESCE(
+		(SELECT parent.root_id FROM chirps AS parent WHERE parent.id = $3::uuid),
+		new_chirp.id
+	),
+	$4::uuid,
+	$5::uuid,
+	$6,
+	$7::timestamp,
+	$8::text,
+	$9
+FROM (SELECT gen_random_uuid() AS id) AS new_chirp
+RETURNING id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body             string
+	UserID           uuid.UUID
+	ParentID         uuid.NullUUID
+	RechirpOfID      uuid.NullUUID
+	QuoteOfID        uuid.NullUUID
+	Published        bool
+	PublishAt        sql.NullTime
+	OriginalBody     sql.NullString
+	ModerationStatus string
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.ParentID,
+		arg.RechirpOfID,
+		arg.QuoteOfID,
+		arg.Published,
+		arg.PublishAt,
+		arg.OriginalBody,
+		arg.ModerationStatus,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +114,17 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.BodyTsv,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.Published,
+		&i.PublishAt,
+		&i.OriginalBody,
+		&i.ModerationStatus,
 	)
 	return i, err
 }
@@ -51,8 +139,26 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteScheduledChirp = `-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1 AND user_id = $2 AND published = false AND publish_at IS NOT NULL
+`
+
+type DeleteScheduledChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteScheduledChirp(ctx context.Context, arg DeleteScheduledChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteScheduledChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status FROM chirps
 WHERE id = $1
 `
 
@@ -65,18 +171,555 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.BodyTsv,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.Published,
+		&i.PublishAt,
+		&i.OriginalBody,
+		&i.ModerationStatus,
+	)
+	return i, err
+}
+
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status FROM chirps
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.BodyTsv,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.Published,
+		&i.PublishAt,
+		&i.OriginalBody,
+		&i.ModerationStatus,
+	)
+	return i, err
+}
+
+const getChirpShareCounts = `-- name: GetChirpShareCounts :many
+SELECT shared.chirp_id::uuid AS chirp_id,
+	COUNT(*) FILTER (WHERE shared.kind = 'rechirp') AS rechirp_count,
+	COUNT(*) FILTER (WHERE shared.kind = 'quote') AS quote_count
+FROM (
+	SELECT rechirp_of_id AS chirp_id, 'rechirp' AS kind FROM chirps
+	WHERE rechirp_of_id = ANY($1::uuid[]) AND deleted_at IS NULL AND published
+	UNION ALL
+	SELECT quote_of_id AS chirp_id, 'quote' AS kind FROM chirps
+	WHERE quote_of_id = ANY($1::uuid[]) AND deleted_at IS NULL AND published
+) AS shared
+GROUP BY shared.chirp_id
+`
+
+type GetChirpShareCountsRow struct {
+	ChirpID      uuid.UUID
+	RechirpCount int64
+	QuoteCount   int64
+}
+
+func (q *Queries) GetChirpShareCounts(ctx context.Context, chirpIds []uuid.UUID) ([]GetChirpShareCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpShareCounts, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpShareCountsRow
+	for rows.Next() {
+		var i GetChirpShareCountsRow
+		if err := rows.Scan(
+			&i.ChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpThread = `-- name: GetChirpThread :many
+WITH RECURSIVE thread AS (
+	SELECT chirps.id,
+		0 AS depth,
+		ARRAY[to_char(chirps.created_at, 'YYYYMMDDHH24MISSUS') || chirps.id::text] AS path
+	FROM chirps
+	WHERE chirps.id = $1::uuid
+	UNION ALL
+	SELECT replies.id,
+		thread.depth + 1,
+		thread.path || (to_char(replies.created_at, 'YYYYMMDDHH24MISSUS') || replies.id::text)
+	FROM chirps AS replies
+	JOIN thread ON replies.parent_id = thread.id
+	WHERE replies.published
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.body_tsv, chirps.edited_at, chirps.parent_id, chirps.root_id, chirps.deleted_at, chirps.rechirp_of_id, chirps.quote_of_id, chirps.published, chirps.publish_at, chirps.original_body, chirps.moderation_status,
+	thread.depth::integer AS depth,
+	author_hidden_from(chirps.user_id, $2::uuid) AS hidden
+FROM thread
+JOIN chirps ON chirps.id = thread.id
+ORDER BY thread.path
+`
+
+type GetChirpThreadParams struct {
+	RootID   uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+type GetChirpThreadRow struct {
+	Chirp  Chirp
+	Depth  int32
+	Hidden bool
+}
+
+func (q *Queries) GetChirpThread(ctx context.Context, arg GetChirpThreadParams) ([]GetChirpThreadRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpThread, arg.RootID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpThreadRow
+	for rows.Next() {
+		var i GetChirpThreadRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.BodyTsv,
+			&i.Chirp.EditedAt,
+			&i.Chirp.ParentID,
+			&i.Chirp.RootID,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.QuoteOfID,
+			&i.Chirp.Published,
+			&i.Chirp.PublishAt,
+			&i.Chirp.OriginalBody,
+			&i.Chirp.ModerationStatus,
+			&i.Depth,
+			&i.Hidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsAsc = `-- name: GetChirpsAsc :many
+SELECT id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status FROM chirps
+WHERE deleted_at IS NULL
+	AND published
+	AND NOT author_hidden_from(chirps.user_id, $1::uuid)
+	AND (COALESCE(cardinality($2::uuid[]), 0) = 0 OR user_id = ANY($2::uuid[]))
+	AND ($3::timestamp IS NULL OR created_at >= $3::timestamp)
+	AND ($4::timestamp IS NULL OR created_at < $4::timestamp)
+	AND ($5::text IS NULL OR body ILIKE '%' || $5::text || '%')
+	AND ($6::timestamp IS NULL
+		OR (created_at, id) > ($6::timestamp, $7::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $8
+`
+
+type GetChirpsAscParams struct {
+	ViewerID       uuid.NullUUID
+	UserIds        []uuid.UUID
+	Since          sql.NullTime
+	Until          sql.NullTime
+	Contains       sql.NullString
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	Limit          int32
+}
+
+func (q *Queries) GetChirpsAsc(ctx context.Context, arg GetChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsAsc,
+		arg.ViewerID,
+		pq.Array(arg.UserIds),
+		arg.Since,
+		arg.Until,
+		arg.Contains,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.BodyTsv,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.Published,
+			&i.PublishAt,
+			&i.OriginalBody,
+			&i.ModerationStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status FROM chirps
+WHERE id = ANY($1::uuid[])
+	AND published
+	AND NOT author_hidden_from(chirps.user_id, $2::uuid)
+`
+
+type GetChirpsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.BodyTsv,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.Published,
+			&i.PublishAt,
+			&i.OriginalBody,
+			&i.ModerationStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByLikes = `-- name: GetChirpsByLikes :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.body_tsv, chirps.edited_at, chirps.parent_id, chirps.root_id, chirps.deleted_at, chirps.rechirp_of_id, chirps.quote_of_id, chirps.published, chirps.publish_at, chirps.original_body, chirps.moderation_status FROM chirps
+LEFT JOIN (
+	SELECT chirp_id, COUNT(*) AS like_count FROM chirp_likes
+	GROUP BY chirp_id
+) AS likes ON likes.chirp_id = chirps.id
+WHERE chirps.deleted_at IS NULL
+	AND chirps.published
+	AND NOT author_hidden_from(chirps.user_id, $1::uuid)
+	AND (COALESCE(cardinality($2::uuid[]), 0) = 0 OR chirps.user_id = ANY($2::uuid[]))
+	AND ($3::timestamp IS NULL OR chirps.created_at >= $3::timestamp)
+	AND ($4::timestamp IS NULL OR chirps.created_at < $4::timestamp)
+	AND ($5::text IS NULL OR chirps.body ILIKE '%' || $5::text || '%')
+ORDER BY
+	CASE WHEN $6::boolean THEN COALESCE(likes.like_count, 0) END DESC,
+	CASE WHEN NOT $6::boolean THEN COALESCE(likes.like_count, 0) END ASC,
+	chirps.created_at DESC,
+	chirps.id DESC
+LIMIT $7 OFFSET $8
+`
+
+type GetChirpsByLikesParams struct {
+	ViewerID   uuid.NullUUID
+	UserIds    []uuid.UUID
+	Since      sql.NullTime
+	Until      sql.NullTime
+	Contains   sql.NullString
+	Descending bool
+	Limit      int32
+	Offset     int32
+}
+
+func (q *Queries) GetChirpsByLikes(ctx context.Context, arg GetChirpsByLikesParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByLikes,
+		arg.ViewerID,
+		pq.Array(arg.UserIds),
+		arg.Since,
+		arg.Until,
+		arg.Contains,
+		arg.Descending,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.BodyTsv,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.Published,
+			&i.PublishAt,
+			&i.OriginalBody,
+			&i.ModerationStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsDesc = `-- name: GetChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status FROM chirps
+WHERE deleted_at IS NULL
+	AND published
+	AND NOT author_hidden_from(chirps.user_id, $1::uuid)
+	AND (COALESCE(cardinality($2::uuid[]), 0) = 0 OR user_id = ANY($2::uuid[]))
+	AND ($3::timestamp IS NULL OR created_at >= $3::timestamp)
+	AND ($4::timestamp IS NULL OR created_at < $4::timestamp)
+	AND ($5::text IS NULL OR body ILIKE '%' || $5::text || '%')
+	AND ($6::timestamp IS NULL
+		OR (created_at, id) < ($6::timestamp, $7::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $8
+`
+
+type GetChirpsDescParams struct {
+	ViewerID        uuid.NullUUID
+	UserIds         []uuid.UUID
+	Since           sql.NullTime
+	Until           sql.NullTime
+	Contains        sql.NullString
+	BeforeCreatedAt sql.NullTime
+	BeforeID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetChirpsDesc(ctx context.Context, arg GetChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsDesc,
+		arg.ViewerID,
+		pq.Array(arg.UserIds),
+		arg.Since,
+		arg.Until,
+		arg.Contains,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.BodyTsv,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.Published,
+			&i.PublishAt,
+			&i.OriginalBody,
+			&i.ModerationStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHeldChirps = `-- name: GetHeldChirps :many
+SELECT id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status FROM chirps
+WHERE moderation_status = 'held'
+	AND deleted_at IS NULL
+	AND ($1::timestamp IS NULL
+		OR (created_at, id) > ($1::timestamp, $2::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $3
+`
+
+type GetHeldChirpsParams struct {
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	Limit          int32
+}
+
+func (q *Queries) GetHeldChirps(ctx context.Context, arg GetHeldChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getHeldChirps, arg.AfterCreatedAt, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.BodyTsv,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.Published,
+			&i.PublishAt,
+			&i.OriginalBody,
+			&i.ModerationStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScheduledChirp = `-- name: GetScheduledChirp :one
+SELECT id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status FROM chirps
+WHERE id = $1 AND user_id = $2 AND published = false AND publish_at IS NOT NULL
+`
+
+type GetScheduledChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetScheduledChirp(ctx context.Context, arg GetScheduledChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getScheduledChirp, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.BodyTsv,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.Published,
+		&i.PublishAt,
+		&i.OriginalBody,
+		&i.ModerationStatus,
 	)
 	return i, err
 }
 
-const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE user_id = COALESCE($1, user_id)
-ORDER BY created_at ASC
+const getScheduledChirps = `-- name: GetScheduledChirps :many
+SELECT id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status FROM chirps
+WHERE user_id = $1 AND published = false AND publish_at IS NOT NULL
+ORDER BY publish_at ASC, id ASC
 `
 
-func (q *Queries) GetChirps(ctx context.Context, userID uuid.NullUUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps, userID)
+func (q *Queries) GetScheduledChirps(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getScheduledChirps, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -90,6 +733,17 @@ func (q *Queries) GetChirps(ctx context.Context, userID uuid.NullUUID) ([]Chirp,
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.BodyTsv,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.Published,
+			&i.PublishAt,
+			&i.OriginalBody,
+			&i.ModerationStatus,
 		); err != nil {
 			return nil, err
 		}
@@ -103,3 +757,421 @@ func (q *Queries) GetChirps(ctx context.Context, userID uuid.NullUUID) ([]Chirp,
 	}
 	return items, nil
 }
+
+const getUserChirpsForExport = `-- name: GetUserChirpsForExport :many
+SELECT id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status FROM chirps
+WHERE user_id = $1
+	AND deleted_at IS NULL
+	AND ($2::timestamp IS NULL
+		OR (created_at, id) > ($2::timestamp, $3::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $4
+`
+
+type GetUserChirpsForExportParams struct {
+	UserID         uuid.UUID
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	Limit          int32
+}
+
+func (q *Queries) GetUserChirpsForExport(ctx context.Context, arg GetUserChirpsForExportParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getUserChirpsForExport,
+		arg.UserID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.BodyTsv,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.Published,
+			&i.PublishAt,
+			&i.OriginalBody,
+			&i.ModerationStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const importChirp = `-- name: ImportChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status, edited_at)
+SELECT
+	new_chirp.id,
+	$1,
+	$2,
+	$3,
+	$4,
+	$5::uuid,
+	COALESCE(
+		(SELECT parent.root_id FROM chirps AS parent WHERE parent.id = $5::uuid),
+		new_chirp.id
+	),
+	$6::uuid,
+	$7::uuid,
+	$8,
+	$9::timestamp,
+	$10::text,
+	$11,
+	$12::timestamp
+FROM (SELECT gen_random_uuid() AS id) AS new_chirp
+RETURNING id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status
+`
+
+type ImportChirpParams struct {
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Body             string
+	UserID           uuid.UUID
+	ParentID         uuid.NullUUID
+	RechirpOfID      uuid.NullUUID
+	QuoteOfID        uuid.NullUUID
+	Published        bool
+	PublishAt        sql.NullTime
+	OriginalBody     sql.NullString
+	ModerationStatus string
+	EditedAt         sql.NullTime
+}
+
+func (q *Queries) ImportChirp(ctx context.Context, arg ImportChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, importChirp,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Body,
+		arg.UserID,
+		arg.ParentID,
+		arg.RechirpOfID,
+		arg.QuoteOfID,
+		arg.Published,
+		arg.PublishAt,
+		arg.OriginalBody,
+		arg.ModerationStatus,
+		arg.EditedAt,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.BodyTsv,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.Published,
+		&i.PublishAt,
+		&i.OriginalBody,
+		&i.ModerationStatus,
+	)
+	return i, err
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+UPDATE chirps
+SET published = true,
+	created_at = NOW(),
+	updated_at = NOW()
+WHERE id IN (
+	SELECT due.id FROM chirps AS due
+	WHERE due.published = false AND due.publish_at <= NOW() AND due.moderation_status = 'approved'
+	ORDER BY due.publish_at
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status
+`
+
+func (q *Queries) PublishDueChirps(ctx context.Context, limit int32) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.BodyTsv,
+			&i.EditedAt,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.Published,
+			&i.PublishAt,
+			&i.OriginalBody,
+			&i.ModerationStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rejectHeldChirp = `-- name: RejectHeldChirp :one
+UPDATE chirps
+SET moderation_status = 'rejected',
+	updated_at = NOW()
+WHERE id = $1 AND moderation_status = 'held'
+RETURNING id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status
+`
+
+func (q *Queries) RejectHeldChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, rejectHeldChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.BodyTsv,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.Published,
+		&i.PublishAt,
+		&i.OriginalBody,
+		&i.ModerationStatus,
+	)
+	return i, err
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.body_tsv, chirps.edited_at, chirps.parent_id, chirps.root_id, chirps.deleted_at, chirps.rechirp_of_id, chirps.quote_of_id, chirps.published, chirps.publish_at, chirps.original_body, chirps.moderation_status,
+	ts_rank(chirps.body_tsv, search_query)::real AS rank,
+	ts_headline(
+		'english',
+		replace(replace(replace(chirps.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+		search_query,
+		'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'
+	) AS highlighted_body
+FROM chirps, websearch_to_tsquery('english', $1::text) AS search_query
+WHERE chirps.body_tsv @@ search_query
+	AND chirps.deleted_at IS NULL
+	AND chirps.published
+	AND NOT author_hidden_from(chirps.user_id, $2::uuid)
+	AND (COALESCE(cardinality($3::uuid[]), 0) = 0 OR chirps.user_id = ANY($3::uuid[]))
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT $4 OFFSET $5
+`
+
+type SearchChirpsParams struct {
+	Query    string
+	ViewerID uuid.NullUUID
+	UserIds  []uuid.UUID
+	Limit    int32
+	Offset   int32
+}
+
+type SearchChirpsRow struct {
+	Chirp           Chirp
+	Rank            float32
+	HighlightedBody string
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.ViewerID,
+		pq.Array(arg.UserIds),
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.BodyTsv,
+			&i.Chirp.EditedAt,
+			&i.Chirp.ParentID,
+			&i.Chirp.RootID,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.QuoteOfID,
+			&i.Chirp.Published,
+			&i.Chirp.PublishAt,
+			&i.Chirp.OriginalBody,
+			&i.Chirp.ModerationStatus,
+			&i.Rank,
+			&i.HighlightedBody,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '',
+	deleted_at = NOW(),
+	updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, id)
+	return err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $1,
+	original_body = $2,
+	moderation_status = $3,
+	published = published AND $3 = 'approved',
+	edited_at = NOW(),
+	updated_at = NOW()
+WHERE id = $4
+RETURNING id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status
+`
+
+type UpdateChirpBodyParams struct {
+	Body             string
+	OriginalBody     sql.NullString
+	ModerationStatus string
+	ID               uuid.UUID
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody,
+		arg.Body,
+		arg.OriginalBody,
+		arg.ModerationStatus,
+		arg.ID,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.BodyTsv,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.Published,
+		&i.PublishAt,
+		&i.OriginalBody,
+		&i.ModerationStatus,
+	)
+	return i, err
+}
+
+const updateScheduledChirp = `-- name: UpdateScheduledChirp :one
+UPDATE chirps
+SET body = $1,
+	original_body = $2,
+	moderation_status = $3,
+	publish_at = $4,
+	updated_at = NOW()
+WHERE id = $5 AND user_id = $6 AND published = false AND publish_at IS NOT NULL
+RETURNING id, created_at, updated_at, body, user_id, body_tsv, edited_at, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status
+`
+
+type UpdateScheduledChirpParams struct {
+	Body             string
+	OriginalBody     sql.NullString
+	ModerationStatus string
+	PublishAt        sql.NullTime
+	ID               uuid.UUID
+	UserID           uuid.UUID
+}
+
+func (q *Queries) UpdateScheduledChirp(ctx context.Context, arg UpdateScheduledChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateScheduledChirp,
+		arg.Body,
+		arg.OriginalBody,
+		arg.ModerationStatus,
+		arg.PublishAt,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.BodyTsv,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.Published,
+		&i.PublishAt,
+		&i.OriginalBody,
+		&i.ModerationStatus,
+	)
+	return i, err
+}
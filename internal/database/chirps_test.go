@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testQueries connects to the migrated database named by TEST_DB_URL and
+// skips the test without one.
+func testQueries(t *testing.T) (*Queries, *sql.DB) {
+	t.Helper()
+
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+	db, err := sql.Open("postgres", dbURL)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return New(db), db
+}
+
+// TestChirpListingsWithoutAuthorFilter runs every listing the way an
+// unfiltered request does: with no author IDs at all, which lib/pq sends
+// as NULL rather than as an empty array.
+func TestChirpListingsWithoutAuthorFilter(t *testing.T) {
+	q, db := testQueries(t)
+	ctx := context.Background()
+
+	marker := "unfiltered" + uuid.NewString()[:8]
+	user, err := q.CreateUser(ctx, CreateUserParams{Email: marker + "@example.com"})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, user.ID)
+	})
+
+	for _, body := range []string{"first " + marker, "second " + marker} {
+		_, err := q.CreateChirp(ctx, CreateChirpParams{Body: body, UserID: user.ID, Published: true, ModerationStatus: "approved"})
+		require.NoError(t, err)
+	}
+	contains := sql.NullString{String: marker, Valid: true}
+
+	asc, err := q.GetChirpsAsc(ctx, GetChirpsAscParams{Contains: contains, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, asc, 2)
+
+	desc, err := q.GetChirpsDesc(ctx, GetChirpsDescParams{Contains: contains, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, desc, 2)
+
+	byLikes, err := q.GetChirpsByLikes(ctx, GetChirpsByLikesParams{Contains: contains, Descending: true, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, byLikes, 2)
+
+	found, err := q.SearchChirps(ctx, SearchChirpsParams{Query: marker, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+}
@@ -12,11 +12,134 @@ import (
 )
 
 type Chirp struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Body             string
+	UserID           uuid.UUID
+	BodyTsv          interface{}
+	EditedAt         sql.NullTime
+	ParentID         uuid.NullUUID
+	RootID           uuid.UUID
+	DeletedAt        sql.NullTime
+	RechirpOfID      uuid.NullUUID
+	QuoteOfID        uuid.NullUUID
+	Published        bool
+	PublishAt        sql.NullTime
+	OriginalBody     sql.NullString
+	ModerationStatus string
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type ChirpLike struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type Conversation struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	LastActivityAt time.Time
+}
+
+type ConversationMember struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type DirectMessage struct {
+	ID             uuid.UUID
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Body           string
+	EditedAt       sql.NullTime
+	DeletedAt      sql.NullTime
+}
+
+type EmailVerificationToken struct {
+	TokenHash string
+	UserID    uuid.UUID
+	Email     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type IdempotencyKey struct {
+	Scope        string
+	Key          string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	RequestHash  string
+	StatusCode   sql.NullInt32
+	ContentType  string
+	ResponseBody []byte
+}
+
+type MediaAttachment struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UserID          uuid.UUID
+	ChirpID         uuid.NullUUID
+	Position        int32
+	AltText         string
+	ContentType     string
+	StorageKey      string
+	Width           int32
+	Height          int32
+	ThumbnailKey    string
+	ThumbnailWidth  int32
+	ThumbnailHeight int32
+	SizeBytes       int32
+}
+
+type ModerationRule struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+	Term      string
+	Action    string
+}
+
+type Notification struct {
+	ID        uuid.UUID
 	UserID    uuid.UUID
+	Type      string
+	ActorID   uuid.NullUUID
+	ChirpID   uuid.NullUUID
+	CreatedAt time.Time
+	ReadAt    sql.NullTime
+}
+
+type NotificationPreference struct {
+	UserID    uuid.UUID
+	Type      string
+	Enabled   bool
+	UpdatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -29,10 +152,29 @@ type RefreshToken struct {
 }
 
 type User struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword sql.NullString
-	IsChirpyRed    bool
+	ID                 uuid.UUID
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Email              string
+	HashedPassword     sql.NullString
+	IsChirpyRed        bool
+	Handle             string
+	DisplayName        string
+	Bio                string
+	Website            string
+	AvatarMediaID      uuid.NullUUID
+	ChirpyRedExpiresAt sql.NullTime
+	EmailVerifiedAt    sql.NullTime
+}
+
+type UserBlock struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type UserMute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
 }
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// Timeline benchmarks run against a migrated database named by TEST_DB_URL
+// and are skipped without one. The seeded graph has a reader who follows
+// timelineFollows of timelineAuthors authors, each with timelineChirps
+// chirps spread over the past year.
+const (
+	timelineAuthors = 5000
+	timelineFollows = 500
+	timelineChirps  = 200
+)
+
+func seedTimeline(b *testing.B) (*Queries, uuid.UUID, []uuid.UUID) {
+	b.Helper()
+
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		b.Skip("TEST_DB_URL is not set")
+	}
+	db, err := sql.Open("postgres", dbURL)
+	require.NoError(b, err)
+	b.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	prefix := "timeline-bench-" + uuid.NewString()
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO users (id, created_at, updated_at, email)
+		SELECT gen_random_uuid(), NOW(), NOW(), $1::text || '-' || n || '@example.com'
+		FROM generate_series(0, $2) AS n`, prefix, timelineAuthors)
+	require.NoError(b, err)
+	b.Cleanup(func() {
+		db.ExecContext(ctx, `DELETE FROM users WHERE email LIKE $1::text || '-%'`, prefix)
+	})
+
+	var readerID uuid.UUID
+	err = db.QueryRowContext(ctx, `SELECT id FROM users WHERE email = $1::text || '-0@example.com'`, prefix).Scan(&readerID)
+	require.NoError(b, err)
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+		SELECT gen_random_uuid(), created_at, created_at, 'Chirp ' || n || ' from ' || users.email, users.id
+		FROM users
+		CROSS JOIN generate_series(1, $2) AS n
+		CROSS JOIN LATERAL (SELECT NOW() - random() * INTERVAL '365 days' AS created_at) AS t
+		WHERE users.email LIKE $1::text || '-%'`, prefix, timelineChirps)
+	require.NoError(b, err)
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO follows (follower_id, followee_id)
+		SELECT $2::uuid, id FROM users
+		WHERE email LIKE $1::text || '-%' AND id <> $2
+		ORDER BY random()
+		LIMIT $3`, prefix, readerID, timelineFollows)
+	require.NoError(b, err)
+
+	rows, err := db.QueryContext(ctx, `SELECT followee_id FROM follows WHERE follower_id = $1`, readerID)
+	require.NoError(b, err)
+	defer rows.Close()
+	authorIDs := []uuid.UUID{readerID}
+	for rows.Next() {
+		var id uuid.UUID
+		require.NoError(b, rows.Scan(&id))
+		authorIDs = append(authorIDs, id)
+	}
+	require.NoError(b, rows.Err())
+
+	_, err = db.ExecContext(ctx, `ANALYZE users, chirps, follows`)
+	require.NoError(b, err)
+
+	return New(db), readerID, authorIDs
+}
+
+func BenchmarkTimeline(b *testing.B) {
+	q, readerID, authorIDs := seedTimeline(b)
+	ctx := context.Background()
+
+	firstPage, err := q.GetTimeline(ctx, GetTimelineParams{UserID: readerID, Limit: 20})
+	require.NoError(b, err)
+	require.Len(b, firstPage, 20)
+	last := firstPage[len(firstPage)-1]
+
+	b.Run("fan-out-on-read/first-page", func(b *testing.B) {
+		for b.Loop() {
+			_, err := q.GetTimeline(ctx, GetTimelineParams{UserID: readerID, Limit: 20})
+			require.NoError(b, err)
+		}
+	})
+	b.Run("fan-out-on-read/next-page", func(b *testing.B) {
+		params := GetTimelineParams{
+			UserID:          readerID,
+			BeforeCreatedAt: sql.NullTime{Time: last.CreatedAt, Valid: true},
+			BeforeID:        uuid.NullUUID{UUID: last.ID, Valid: true},
+			Limit:           20,
+		}
+		for b.Loop() {
+			_, err := q.GetTimeline(ctx, params)
+			require.NoError(b, err)
+		}
+	})
+	// The author filter on the global listing is the query the timeline
+	// would otherwise be built from.
+	b.Run("author-filter/first-page", func(b *testing.B) {
+		for b.Loop() {
+			_, err := q.GetChirpsDesc(ctx, GetChirpsDescParams{UserIds: authorIDs, Limit: 20})
+			require.NoError(b, err)
+		}
+	})
+}
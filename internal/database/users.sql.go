@@ -10,27 +10,30 @@ import (
 	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid(),
 	NOW(),
 	NOW(),
 	$1,
-	$2
+	$2,
+	COALESCE($3::text, 'user_' || substr(md5(gen_random_uuid()::text), 1, 10))
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, website, avatar_media_id, chirpy_red_expires_at, email_verified_at
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword sql.NullString
+	Handle         sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -39,12 +42,19 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.ChirpyRedExpiresAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, website, avatar_media_id, chirpy_red_expires_at, email_verified_at FROM users
 WHERE id = $1
 `
 
@@ -58,12 +68,19 @@ func (q *Queries) GetUser(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.ChirpyRedExpiresAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, website, avatar_media_id, chirpy_red_expires_at, email_verified_at FROM users
 WHERE email = $1
 `
 
@@ -77,10 +94,151 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.ChirpyRedExpiresAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, website, avatar_media_id, chirpy_red_expires_at, email_verified_at FROM users
+WHERE lower(handle) = lower($1)
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.ChirpyRedExpiresAt,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const getUserStats = `-- name: GetUserStats :one
+SELECT
+	(SELECT COUNT(*) FROM chirps WHERE chirps.user_id = $1 AND published AND deleted_at IS NULL) AS chirp_count,
+	(SELECT COUNT(*) FROM follows WHERE followee_id = $1) AS follower_count,
+	(SELECT COUNT(*) FROM follows WHERE follower_id = $1) AS following_count
+`
+
+type GetUserStatsRow struct {
+	ChirpCount     int64
+	FollowerCount  int64
+	FollowingCount int64
+}
+
+func (q *Queries) GetUserStats(ctx context.Context, userID uuid.UUID) (GetUserStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserStats, userID)
+	var i GetUserStatsRow
+	err := row.Scan(
+		&i.ChirpCount,
+		&i.FollowerCount,
+		&i.FollowingCount,
+	)
+	return i, err
+}
+
+const getUserSummaries = `-- name: GetUserSummaries :many
+SELECT users.id, users.handle, users.display_name, media_attachments.storage_key AS avatar_key
+FROM users
+LEFT JOIN media_attachments ON media_attachments.id = users.avatar_media_id
+WHERE users.id = ANY($1::uuid[])
+`
+
+type GetUserSummariesRow struct {
+	ID          uuid.UUID
+	Handle      string
+	DisplayName string
+	AvatarKey   sql.NullString
+}
+
+func (q *Queries) GetUserSummaries(ctx context.Context, userIds []uuid.UUID) ([]GetUserSummariesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserSummaries, pq.Array(userIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserSummariesRow
+	for rows.Next() {
+		var i GetUserSummariesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Handle,
+			&i.DisplayName,
+			&i.AvatarKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersByHandles = `-- name: GetUsersByHandles :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, website, avatar_media_id, chirpy_red_expires_at, email_verified_at FROM users
+WHERE lower(handle) = ANY($1::text[])
+`
+
+func (q *Queries) GetUsersByHandles(ctx context.Context, handles []string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByHandles, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.Website,
+			&i.AvatarMediaID,
+			&i.ChirpyRedExpiresAt,
+			&i.EmailVerifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const resetUsers = `-- name: ResetUsers :exec
 TRUNCATE TABLE users
 `
@@ -92,21 +250,40 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
-SET email = $2,
-	hashed_password = $3,
+SET email = $1,
+	hashed_password = $2,
+	handle = $3,
+	display_name = $4,
+	bio = $5,
+	website = $6,
+	avatar_media_id = $7,
 	updated_at = NOW()
-WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+WHERE id = $8
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, website, avatar_media_id, chirpy_red_expires_at, email_verified_at
 `
 
 type UpdateUserParams struct {
-	ID             uuid.UUID
 	Email          string
 	HashedPassword sql.NullString
+	Handle         string
+	DisplayName    string
+	Bio            string
+	Website        string
+	AvatarMediaID  uuid.NullUUID
+	ID             uuid.UUID
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUser, arg.ID, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, updateUser,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.Website,
+		arg.AvatarMediaID,
+		arg.ID,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -115,17 +292,65 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.ChirpyRedExpiresAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const upgradeUser = `-- name: UpgradeUser :exec
 UPDATE users
-SET is_chirpy_red = true
+SET is_chirpy_red = true,
+	chirpy_red_expires_at = $2
 WHERE id = $1
 `
 
-func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, upgradeUser, id)
+type UpgradeUserParams struct {
+	ID                 uuid.UUID
+	ChirpyRedExpiresAt sql.NullTime
+}
+
+func (q *Queries) UpgradeUser(ctx context.Context, arg UpgradeUserParams) error {
+	_, err := q.db.ExecContext(ctx, upgradeUser, arg.ID, arg.ChirpyRedExpiresAt)
 	return err
 }
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users
+SET email = $2,
+	email_verified_at = NOW(),
+	updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, website, avatar_media_id, chirpy_red_expires_at, email_verified_at
+`
+
+type VerifyUserEmailParams struct {
+	ID    uuid.UUID
+	Email string
+}
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserEmail, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.ChirpyRedExpiresAt,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
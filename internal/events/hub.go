@@ -0,0 +1,119 @@
+// Package events fans chirp events out to streaming clients and keeps a
+// short history so reconnecting clients can catch up.
+package events
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before
+// it is dropped. A dropped client reconnects and resumes from its last
+// event ID.
+const subscriberBuffer = 64
+
+type Event struct {
+	// ID orders events across every server instance.
+	ID     int64
+	Type   string
+	UserID uuid.UUID
+	Data   []byte
+}
+
+type Subscription struct {
+	// C delivers events in the order they reach the hub. It is closed when
+	// the subscriber falls too far behind or unsubscribes.
+	C <-chan Event
+
+	c      chan Event
+	filter func(Event) bool
+}
+
+// Hub is safe for concurrent use. Events published more than once, as
+// happens when an instance hears its own events back through the
+// database, are delivered once.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	history     []Event
+	historySize int
+}
+
+func NewHub(historySize int) *Hub {
+	return &Hub{
+		subscribers: make(map[*Subscription]struct{}),
+		historySize: historySize,
+	}
+}
+
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Events from other instances can arrive slightly out of order, so
+	// history is kept sorted by ID.
+	i := sort.Search(len(h.history), func(i int) bool { return h.history[i].ID >= event.ID })
+	if i < len(h.history) && h.history[i].ID == event.ID {
+		return
+	}
+	if len(h.history) == h.historySize && i == 0 {
+		// Older than anything we remember; only a duplicate could be this
+		// late.
+		return
+	}
+	h.history = append(h.history, Event{})
+	copy(h.history[i+1:], h.history[i:])
+	h.history[i] = event
+	if len(h.history) > h.historySize {
+		h.history = h.history[1:]
+	}
+
+	for sub := range h.subscribers {
+		if !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.c <- event:
+		default:
+			delete(h.subscribers, sub)
+			close(sub.c)
+		}
+	}
+}
+
+// Subscribe registers for events that pass filter. When resume is true,
+// the remembered events after lastEventID that pass filter are returned to
+// be sent before anything from C.
+func (h *Hub) Subscribe(filter func(Event) bool, lastEventID int64, resume bool) (*Subscription, []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: c, c: c, filter: filter}
+	h.subscribers[sub] = struct{}{}
+
+	if !resume {
+		return sub, nil
+	}
+
+	var missed []Event
+	start := sort.Search(len(h.history), func(i int) bool { return h.history[i].ID > lastEventID })
+	for _, event := range h.history[start:] {
+		if filter(event) {
+			missed = append(missed, event)
+		}
+	}
+	return sub, missed
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.c)
+	}
+}
@@ -0,0 +1,59 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func all(Event) bool { return true }
+
+func TestPublishDeliversOnce(t *testing.T) {
+	hub := NewHub(10)
+	sub, missed := hub.Subscribe(all, 0, false)
+	assert.Empty(t, missed)
+
+	hub.Publish(Event{ID: 1, Type: "chirp.created"})
+	hub.Publish(Event{ID: 1, Type: "chirp.created"})
+	hub.Publish(Event{ID: 2, Type: "chirp.deleted"})
+
+	assert.Equal(t, int64(1), (<-sub.C).ID)
+	assert.Equal(t, int64(2), (<-sub.C).ID)
+	assert.Len(t, sub.C, 0, "Duplicate event should not be delivered")
+}
+
+func TestSubscribeResumes(t *testing.T) {
+	hub := NewHub(3)
+	author := uuid.New()
+	for _, id := range []int64{1, 2, 4, 3, 5} {
+		hub.Publish(Event{ID: id, UserID: author})
+	}
+
+	_, missed := hub.Subscribe(all, 2, true)
+	ids := make([]int64, len(missed))
+	for i, event := range missed {
+		ids[i] = event.ID
+	}
+	assert.Equal(t, []int64{3, 4, 5}, ids, "Missed events should come back in ID order")
+
+	_, missed = hub.Subscribe(func(e Event) bool { return e.UserID != author }, 0, true)
+	assert.Empty(t, missed, "Filtered events should not be replayed")
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	hub := NewHub(10)
+	sub, _ := hub.Subscribe(all, 0, false)
+
+	for id := int64(1); id <= subscriberBuffer+1; id++ {
+		hub.Publish(Event{ID: id})
+	}
+
+	received := 0
+	for range sub.C {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+
+	hub.Unsubscribe(sub)
+}
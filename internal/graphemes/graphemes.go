@@ -0,0 +1,26 @@
+// Package graphemes counts user-perceived characters: extended grapheme
+// clusters as defined by Unicode Standard Annex #29. Segmentation itself
+// is left to github.com/rivo/uniseg, which tracks the Unicode tables.
+package graphemes
+
+import "github.com/rivo/uniseg"
+
+// Count returns the number of extended grapheme clusters in s. An emoji
+// built from several code points, a letter with combining accents and a
+// Hangul syllable written as separate jamo each count once.
+func Count(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
+
+// Prefix returns the first n extended grapheme clusters of s, or all of s
+// if it has no more than n.
+func Prefix(s string, n int) string {
+	end := 0
+	state := -1
+	for i := 0; i < n && end < len(s); i++ {
+		var cluster string
+		cluster, _, _, state = uniseg.FirstGraphemeClusterInString(s[end:], state)
+		end += len(cluster)
+	}
+	return s[:end]
+}
@@ -0,0 +1,46 @@
+package graphemes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "empty", text: "", want: 0},
+		{name: "ascii", text: "hello", want: 5},
+		{name: "cyrillic", text: "привет", want: 6},
+		{name: "combining accent", text: "e\u0301te\u0301", want: 3},
+		{name: "crlf", text: "a\r\nb", want: 3},
+		{name: "skin tone", text: "👍🏽", want: 1},
+		{name: "zwj family", text: "👨\u200d👩\u200d👧\u200d👦", want: 1},
+		{name: "flags", text: "🇺🇸🇫🇷", want: 2},
+		{name: "odd regional indicator", text: "🇺🇸🇫", want: 2},
+		{name: "keycap", text: "1️⃣", want: 1},
+		{name: "tag flag", text: "🏴\U000e0067\U000e0062\U000e0065\U000e006e\U000e0067\U000e007f", want: 1},
+		{name: "hangul jamo", text: "각", want: 1},
+		{name: "hangul syllables", text: "한국어", want: 3},
+		{name: "devanagari vowel signs", text: "नमस्ते", want: 4},
+		{name: "emoji run", text: "😀😀😀", want: 3},
+		{name: "hangul jamo syllables", text: "\u1100\u1161\u11a8\u1102\u1161", want: 2},
+		{name: "thai spacing mark", text: "กำ", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Count(tt.text))
+		})
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	assert.Equal(t, "ab", Prefix("abc", 2))
+	assert.Equal(t, "a👍🏽", Prefix("a👍🏽🇺🇸", 2))
+	assert.Equal(t, "abc", Prefix("abc", 10))
+	assert.Equal(t, "", Prefix("abc", 0))
+}
@@ -0,0 +1,89 @@
+package handles
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	minLength = 3
+	maxLength = 15
+
+	// GeneratedPrefix starts the handles given to users who have not picked
+	// one. Users can't choose handles with it, so generated handles never
+	// collide with chosen ones.
+	GeneratedPrefix = "user_"
+)
+
+var reserved = map[string]struct{}{
+	"admin": {}, "administrator": {}, "api": {}, "app": {}, "chirpy": {},
+	"everyone": {}, "help": {}, "here": {}, "login": {}, "logout": {},
+	"me": {}, "mod": {}, "moderator": {}, "null": {}, "official": {},
+	"polka": {}, "root": {}, "settings": {}, "signup": {}, "staff": {},
+	"support": {}, "system": {}, "undefined": {},
+
+	// Path segments under /api/users/{id}/ and /api/users/me/. A handle
+	// equal to one of these would be routed away from
+	// /api/users/by-handle/{handle}.
+	"block": {}, "blocks": {}, "export": {}, "follow": {}, "followers": {},
+	"following": {}, "import": {}, "likes": {}, "mute": {}, "mutes": {},
+}
+
+// Validate checks a handle a user wants to take. Handles are 3 to 15 ASCII
+// letters, digits or underscores, are compared case-insensitively, and
+// can't be one of the reserved names.
+func Validate(handle string) error {
+	if len(handle) < minLength || len(handle) > maxLength {
+		return errors.New("handle must be between 3 and 15 characters")
+	}
+	for i := 0; i < len(handle); i++ {
+		if !isHandleByte(handle[i]) {
+			return errors.New("handle may only contain letters, digits and underscores")
+		}
+	}
+
+	lower := strings.ToLower(handle)
+	if _, ok := reserved[lower]; ok {
+		return errors.New("handle is reserved")
+	}
+	if strings.HasPrefix(lower, GeneratedPrefix) {
+		return errors.New("handle is reserved")
+	}
+	return nil
+}
+
+// Mentions returns the distinct handles mentioned in body, lowercased and in
+// order of first appearance. A mention is an '@' that does not follow a
+// handle character, followed by a well-formed handle.
+func Mentions(body string) []string {
+	var mentions []string
+	seen := map[string]struct{}{}
+
+	for i := 0; i < len(body); i++ {
+		if body[i] != '@' || (i > 0 && isHandleByte(body[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(body) && isHandleByte(body[end]) {
+			end++
+		}
+
+		handle := strings.ToLower(body[i+1 : end])
+		i = end - 1
+		if len(handle) < minLength || len(handle) > maxLength {
+			continue
+		}
+		if _, ok := seen[handle]; ok {
+			continue
+		}
+		seen[handle] = struct{}{}
+		mentions = append(mentions, handle)
+	}
+
+	return mentions
+}
+
+func isHandleByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
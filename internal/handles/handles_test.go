@@ -0,0 +1,56 @@
+package handles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		handle  string
+		wantErr bool
+	}{
+		{name: "letters digits and underscores", handle: "Chirpy_Fan_42", wantErr: false},
+		{name: "too short", handle: "ab", wantErr: true},
+		{name: "too long", handle: "abcdefghijklmnop", wantErr: true},
+		{name: "dash", handle: "chirpy-fan", wantErr: true},
+		{name: "non-ASCII letter", handle: "chïrpy", wantErr: true},
+		{name: "reserved in any case", handle: "Admin", wantErr: true},
+		{name: "route segment", handle: "followers", wantErr: true},
+		{name: "generated prefix", handle: "USER_abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.handle)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "single mention", body: "hi @Alice!", want: []string{"alice"}},
+		{name: "duplicates differing in case", body: "@bob @BOB @Bob", want: []string{"bob"}},
+		{name: "email address is not a mention", body: "mail me at bob@example.com", want: nil},
+		{name: "too short", body: "@ab and @", want: nil},
+		{name: "too long", body: "@abcdefghijklmnop", want: nil},
+		{name: "adjacent mentions", body: "@carol@dave", want: []string{"carol"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Mentions(tt.body))
+		})
+	}
+}
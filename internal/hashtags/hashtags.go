@@ -0,0 +1,77 @@
+package hashtags
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxTagLength = 100
+
+// Extract returns the distinct hashtags in body, normalized and in order of
+// first appearance. A hashtag is a '#' that does not follow a tag character,
+// followed by letters, digits, marks or underscores with at least one letter.
+func Extract(body string) []string {
+	var tags []string
+	seen := map[string]struct{}{}
+
+	var previous rune
+	for i, r := range body {
+		if r != '#' || isTagRune(previous) {
+			previous = r
+			continue
+		}
+		previous = r
+
+		end := i + 1
+		for end < len(body) {
+			next, size := utf8.DecodeRuneInString(body[end:])
+			if !isTagRune(next) {
+				break
+			}
+			end += size
+		}
+
+		tag, err := Normalize(body[i+1 : end])
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// Normalize turns a tag as typed by a user, with or without its leading
+// '#', into the case-insensitive form it is stored and looked up under.
+func Normalize(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+
+	if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+		return "", errors.New("hashtag must be between 1 and 100 characters")
+	}
+
+	hasLetter := false
+	for _, r := range tag {
+		if !isTagRune(r) {
+			return "", errors.New("hashtag may only contain letters, digits and underscores")
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	if !hasLetter {
+		return "", errors.New("hashtag must contain a letter")
+	}
+
+	return tag, nil
+}
+
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
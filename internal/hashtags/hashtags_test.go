@@ -0,0 +1,43 @@
+package hashtags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "single tag", body: "hello #Chirpy", want: []string{"chirpy"}},
+		{name: "punctuation ends a tag", body: "#go, #rust!", want: []string{"go", "rust"}},
+		{name: "duplicates differing in case", body: "#Go #GO #go", want: []string{"go"}},
+		{name: "unicode letters", body: "привет #Москва и #東京", want: []string{"москва", "東京"}},
+		{name: "digits only is not a tag", body: "issue #123", want: nil},
+		{name: "tag inside a word", body: "c#sharp and a#b", want: nil},
+		{name: "adjacent tags", body: "#one#two", want: []string{"one"}},
+		{name: "lone hash", body: "# nothing", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Extract(tt.body))
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tag, err := Normalize("#Chirpy_2025")
+	require.NoError(t, err, "Expected tag to be valid")
+	assert.Equal(t, "chirpy_2025", tag, "Tag should be lowercased without its hash")
+
+	_, err = Normalize("not-a-tag")
+	assert.Error(t, err, "Expected error for tag with a dash")
+
+	_, err = Normalize("#")
+	assert.Error(t, err, "Expected error for empty tag")
+}
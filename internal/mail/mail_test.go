@@ -0,0 +1,173 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readMessage(t *testing.T, data []byte) (*mail.Message, string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	return msg, string(body)
+}
+
+func TestOutboxWritesOneFilePerMessage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	outbox, err := NewOutbox(dir, "Chirpy <no-reply@chirpy.test>")
+	require.NoError(t, err)
+
+	require.NoError(t, outbox.Send(context.Background(), Message{To: "walt@example.com", Subject: "Verify your email", Body: "Hi!\nClick the link."}))
+	require.NoError(t, outbox.Send(context.Background(), Message{To: "saul@example.com", Subject: "Vérifiez", Body: "Ça va ?"}))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+	msg, body := readMessage(t, data)
+	assert.Equal(t, "walt@example.com", msg.Header.Get("To"))
+	assert.Equal(t, "Chirpy <no-reply@chirpy.test>", msg.Header.Get("From"))
+	assert.Equal(t, "Verify your email", msg.Header.Get("Subject"))
+	assert.Equal(t, "Hi!\r\nClick the link.\r\n", body)
+
+	data, err = os.ReadFile(filepath.Join(dir, entries[1].Name()))
+	require.NoError(t, err)
+	msg, body = readMessage(t, data)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Vérifiez", subject)
+	assert.Equal(t, "Ça va ?\r\n", body)
+}
+
+func TestFormatRejectsHeaderInjection(t *testing.T) {
+	_, err := format("no-reply@chirpy.test", Message{To: "walt@example.com\r\nBcc: everyone@example.com", Subject: "Hi"}, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+
+	_, err = format("no-reply@chirpy.test", Message{To: "walt@example.com", Subject: "Hi\nBcc: everyone@example.com"}, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+
+	_, err = format("no-reply@chirpy.test", Message{To: "not an address", Subject: "Hi"}, time.Now())
+	assert.Error(t, err)
+}
+
+// fakeSMTPServer accepts one session on a local port and returns what the
+// client sent in the DATA command.
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		io.WriteString(conn, "220 localhost ESMTP\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch command := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				io.WriteString(conn, "250 localhost\r\n")
+			case strings.HasPrefix(command, "MAIL FROM:"), strings.HasPrefix(command, "RCPT TO:"):
+				io.WriteString(conn, "250 OK\r\n")
+			case command == "DATA":
+				io.WriteString(conn, "354 Go ahead\r\n")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				received <- data.String()
+				io.WriteString(conn, "250 Queued\r\n")
+			case command == "QUIT":
+				io.WriteString(conn, "221 Bye\r\n")
+				return
+			default:
+				io.WriteString(conn, "502 Not implemented\r\n")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestSMTPSenderDeliversMessage(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	sender, err := NewSMTPSender(addr, "Chirpy <no-reply@chirpy.test>", "", "")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, sender.Send(ctx, Message{To: "walt@example.com", Subject: "Verify your email", Body: "Click the link."}))
+
+	msg, body := readMessage(t, []byte(<-received))
+	assert.Equal(t, "walt@example.com", msg.Header.Get("To"))
+	assert.Equal(t, "Verify your email", msg.Header.Get("Subject"))
+	assert.Equal(t, "Click the link.\r\n", body)
+}
+
+func TestNewSMTPSenderValidatesConfig(t *testing.T) {
+	_, err := NewSMTPSender("localhost", "no-reply@chirpy.test", "", "")
+	assert.Error(t, err)
+
+	_, err = NewSMTPSender("localhost:25", "not an address", "", "")
+	assert.Error(t, err)
+}
+
+func TestSMTPSenderGivesUpOnSilentRelay(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	go func() {
+		// Accept the connection but never send a greeting.
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		<-done
+		conn.Close()
+	}()
+
+	sender, err := NewSMTPSender(listener.Addr().String(), "no-reply@chirpy.test", "", "")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = sender.Send(ctx, Message{To: "walt@example.com", Subject: "Hi", Body: "Hello"})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
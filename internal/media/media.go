@@ -0,0 +1,130 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	MaxUploadBytes = 5 << 20
+
+	maxPixels     = 40_000_000
+	thumbnailSize = 320
+	jpegQuality   = 90
+)
+
+var (
+	ErrUnsupportedType = errors.New("media must be a JPEG, PNG or GIF image")
+	ErrTooLarge        = errors.New("media is too large")
+)
+
+type Image struct {
+	ContentType string
+	Extension   string
+	Data        []byte
+	Width       int
+	Height      int
+}
+
+type Processed struct {
+	Original  Image
+	Thumbnail Image
+}
+
+// Process validates an uploaded image and re-encodes it. Re-encoding drops
+// every metadata segment, EXIF included, so the JPEG orientation tag is
+// applied to the pixels first to keep photos the right way up.
+func Process(data []byte) (Processed, error) {
+	if len(data) > MaxUploadBytes {
+		return Processed{}, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return Processed{}, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, err
+	}
+	if config.Width*config.Height > maxPixels {
+		return Processed{}, ErrTooLarge
+	}
+
+	var original bytes.Buffer
+	var img image.Image
+	switch contentType {
+	case "image/jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Processed{}, err
+		}
+		img = applyOrientation(img, jpegOrientation(data))
+		err = jpeg.Encode(&original, img, &jpeg.Options{Quality: jpegQuality})
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Processed{}, err
+		}
+		err = png.Encode(&original, img)
+	case "image/gif":
+		var animation *gif.GIF
+		animation, err = gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Processed{}, err
+		}
+		img = animation.Image[0]
+		err = gif.EncodeAll(&original, animation)
+	}
+	if err != nil {
+		return Processed{}, err
+	}
+
+	bounds := img.Bounds()
+	processed := Processed{
+		Original: Image{
+			ContentType: contentType,
+			Extension:   extensions[contentType],
+			Data:        original.Bytes(),
+			Width:       bounds.Dx(),
+			Height:      bounds.Dy(),
+		},
+	}
+
+	thumb := thumbnail(img, thumbnailSize)
+	var thumbData bytes.Buffer
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&thumbData, thumb, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		contentType = "image/png"
+		err = png.Encode(&thumbData, thumb)
+	}
+	if err != nil {
+		return Processed{}, err
+	}
+
+	thumbBounds := thumb.Bounds()
+	processed.Thumbnail = Image{
+		ContentType: contentType,
+		Extension:   extensions[contentType],
+		Data:        thumbData.Bytes(),
+		Width:       thumbBounds.Dx(),
+		Height:      thumbBounds.Dy(),
+	}
+
+	return processed, nil
+}
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
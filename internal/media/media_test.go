@@ -0,0 +1,81 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// withOrientation inserts an EXIF segment carrying the given orientation
+// right after the JPEG start-of-image marker.
+func withOrientation(jpegData []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a")
+	tiff = binary.BigEndian.AppendUint32(tiff, 8)
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, segment...)
+	return append(out, jpegData[2:]...)
+}
+
+func TestProcess_JPEGStripsExifAndAppliesOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, testImage(40, 20), nil), "Error encoding JPEG")
+	data := withOrientation(buf.Bytes(), 6)
+	require.Equal(t, 6, jpegOrientation(data), "Test image should carry orientation 6")
+
+	processed, err := Process(data)
+	require.NoError(t, err, "Expected JPEG to be accepted")
+
+	assert.Equal(t, "image/jpeg", processed.Original.ContentType)
+	assert.Equal(t, 20, processed.Original.Width, "Width should be rotated")
+	assert.Equal(t, 40, processed.Original.Height, "Height should be rotated")
+	assert.False(t, bytes.Contains(processed.Original.Data, []byte("Exif")), "EXIF should be stripped")
+	assert.Equal(t, 1, jpegOrientation(processed.Original.Data), "Orientation tag should be gone")
+}
+
+func TestProcess_PNGThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage(800, 400)), "Error encoding PNG")
+
+	processed, err := Process(buf.Bytes())
+	require.NoError(t, err, "Expected PNG to be accepted")
+
+	assert.Equal(t, 800, processed.Original.Width)
+	assert.Equal(t, 400, processed.Original.Height)
+	assert.Equal(t, "image/png", processed.Thumbnail.ContentType)
+	assert.Equal(t, 320, processed.Thumbnail.Width, "Thumbnail should fit the box")
+	assert.Equal(t, 160, processed.Thumbnail.Height, "Thumbnail should keep the aspect ratio")
+}
+
+func TestProcess_RejectsUnsupportedType(t *testing.T) {
+	_, err := Process([]byte("definitely not an image"))
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
@@ -0,0 +1,250 @@
+package moderation
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Action string
+
+const (
+	ActionNone   Action = ""
+	ActionMask   Action = "mask"
+	ActionHold   Action = "hold"
+	ActionReject Action = "reject"
+)
+
+const mask = "****"
+
+var ErrEmptyTerm = errors.New("term must contain at least one letter or digit")
+
+func ParseAction(action string) (Action, error) {
+	switch Action(action) {
+	case ActionMask, ActionHold, ActionReject:
+		return Action(action), nil
+	}
+	return ActionNone, errors.New("action must be one of mask, hold or reject")
+}
+
+func (a Action) severity() int {
+	switch a {
+	case ActionMask:
+		return 1
+	case ActionHold:
+		return 2
+	case ActionReject:
+		return 3
+	}
+	return 0
+}
+
+// Rule is a term, a single word or a phrase, and what to do with chirps that
+// contain it.
+type Rule struct {
+	Term   string
+	Action Action
+}
+
+type Result struct {
+	// Body is the input with every term matched by a mask rule replaced.
+	Body string
+	// Action is the most severe action among the matched rules.
+	Action Action
+	// Matched lists the terms that matched, in rule order.
+	Matched []string
+}
+
+// NormalizeTerm turns a term as entered by a moderator into the form Apply
+// matches against.
+func NormalizeTerm(term string) (string, error) {
+	words := tokenize(term)
+	if len(words) == 0 {
+		return "", ErrEmptyTerm
+	}
+
+	normalized := make([]string, len(words))
+	for i, word := range words {
+		normalized[i] = word.trimmed
+	}
+	return strings.Join(normalized, " "), nil
+}
+
+// Apply runs body past every rule. Words are compared after normalization,
+// so punctuation around a word, odd casing, lookalike characters and
+// leetspeak do not hide it.
+func Apply(body string, rules []Rule) Result {
+	words := tokenize(body)
+	result := Result{Body: body}
+
+	var spans [][2]int
+	for _, rule := range rules {
+		term, err := NormalizeTerm(rule.Term)
+		if err != nil {
+			continue
+		}
+		terms := strings.Split(term, " ")
+		matched := false
+
+		for start := 0; start+len(terms) <= len(words); start++ {
+			span, ok := matchAt(words[start:start+len(terms)], terms)
+			if !ok {
+				continue
+			}
+			matched = true
+			if rule.Action == ActionMask {
+				spans = append(spans, span)
+			}
+		}
+
+		if matched {
+			result.Matched = append(result.Matched, rule.Term)
+			if rule.Action.severity() > result.Action.severity() {
+				result.Action = rule.Action
+			}
+		}
+	}
+
+	result.Body = maskSpans(body, spans)
+	return result
+}
+
+// word is a run of word characters in the input. Leetspeak symbols count as
+// word characters, but a symbol at either end may just be punctuation, so
+// the word is also kept with those trimmed off.
+type word struct {
+	full        string
+	trimmed     string
+	start       int
+	end         int
+	trimmedFrom int
+	trimmedTo   int
+}
+
+func matchAt(words []word, terms []string) ([2]int, bool) {
+	span := [2]int{words[0].start, words[len(words)-1].end}
+	for i, w := range words {
+		switch terms[i] {
+		case w.full:
+		case w.trimmed:
+			if i == 0 {
+				span[0] = w.trimmedFrom
+			}
+			if i == len(words)-1 {
+				span[1] = w.trimmedTo
+			}
+		default:
+			return [2]int{}, false
+		}
+	}
+	return span, true
+}
+
+func tokenize(text string) []word {
+	var words []word
+
+	start := -1
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			words = appendWord(words, text, start, i)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = appendWord(words, text, start, len(text))
+	}
+
+	return words
+}
+
+func appendWord(words []word, text string, start, end int) []word {
+	trimmedFrom, trimmedTo := start, end
+	for trimmedFrom < trimmedTo {
+		r, size := utf8.DecodeRuneInString(text[trimmedFrom:trimmedTo])
+		if !isEdgeSymbol(r) {
+			break
+		}
+		trimmedFrom += size
+	}
+	for trimmedTo > trimmedFrom {
+		r, size := utf8.DecodeLastRuneInString(text[trimmedFrom:trimmedTo])
+		if !isEdgeSymbol(r) {
+			break
+		}
+		trimmedTo -= size
+	}
+
+	trimmed := Normalize(text[trimmedFrom:trimmedTo])
+	if trimmed == "" {
+		return words
+	}
+
+	return append(words, word{
+		full:        Normalize(text[start:end]),
+		trimmed:     trimmed,
+		start:       start,
+		end:         end,
+		trimmedFrom: trimmedFrom,
+		trimmedTo:   trimmedTo,
+	})
+}
+
+func isWordRune(r rune) bool {
+	if isZeroWidth(r) || unicode.Is(unicode.Mn, r) {
+		return true
+	}
+	for _, folded := range foldRune(r) {
+		if unicode.IsLetter(folded) || unicode.IsDigit(folded) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEdgeSymbol reports whether r is a leetspeak symbol that is also plain
+// punctuation, or something invisible.
+func isEdgeSymbol(r rune) bool {
+	if isZeroWidth(r) {
+		return true
+	}
+	r = compatible(r)
+	_, isLeet := leetspeak[r]
+	return isLeet && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func maskSpans(body string, spans [][2]int) string {
+	if len(spans) == 0 {
+		return body
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, span := range sortedSpans(spans) {
+		if span[0] < last {
+			continue
+		}
+		b.WriteString(body[last:span[0]])
+		b.WriteString(mask)
+		last = span[1]
+	}
+	b.WriteString(body[last:])
+
+	return b.String()
+}
+
+func sortedSpans(spans [][2]int) [][2]int {
+	sorted := append([][2]int{}, spans...)
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && sorted[j][0] < sorted[j-1][0]; j-- {
+			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
+		}
+	}
+	return sorted
+}
@@ -0,0 +1,85 @@
+package moderation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "case", text: "KerFuffle", want: "kerfuffle"},
+		{name: "fullwidth", text: "ｋｅｒｆｕｆｆｌｅ", want: "kerfuffle"},
+		{name: "circled", text: "ⓚⓔⓡⓕⓤⓕⓕⓛⓔ", want: "kerfuffle"},
+		{name: "mathematical", text: "𝐤𝐞𝐫𝐟𝐮𝐟𝐟𝐥𝐞", want: "kerfuffle"},
+		{name: "zero width", text: "ker​fuf‍fle", want: "kerfuffle"},
+		{name: "combining marks", text: "kérfuffle", want: "kerfuffle"},
+		{name: "precomposed diacritics", text: "kérfüffle", want: "kerfuffle"},
+		{name: "stroked letters", text: "kerfuffłe", want: "kerfuffle"},
+		{name: "leetspeak", text: "5h4rb3rt", want: "sharbert"},
+		{name: "one reads as i", text: "f1x3d", want: "fixed"},
+		{name: "cyrillic homoglyphs", text: "kеrfuffle", want: "kerfuffle"},
+		{name: "ligatures", text: "kerfuﬄe", want: "kerfuffle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.text))
+		})
+	}
+}
+
+func TestNormalizeKeepsLettersApart(t *testing.T) {
+	assert.NotEqual(t, Normalize("fail"), Normalize("fall"))
+	assert.NotEqual(t, Normalize("ick"), Normalize("lick"))
+}
+
+func TestApply(t *testing.T) {
+	rules := []Rule{
+		{Term: "kerfuffle", Action: ActionMask},
+		{Term: "sharbert", Action: ActionMask},
+		{Term: "fornax", Action: ActionHold},
+		{Term: "bad idea", Action: ActionReject},
+		{Term: "fail", Action: ActionReject},
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantBody   string
+		wantAction Action
+	}{
+		{name: "clean", body: "what a lovely day", wantBody: "what a lovely day", wantAction: ActionNone},
+		{name: "plain word", body: "such a kerfuffle today", wantBody: "such a **** today", wantAction: ActionMask},
+		{name: "trailing punctuation", body: "Kerfuffle! Sharbert?", wantBody: "****! ****?", wantAction: ActionMask},
+		{name: "leetspeak with symbol", body: "$harbert again", wantBody: "**** again", wantAction: ActionMask},
+		{name: "zero width inside", body: "a ker​fuffle", wantBody: "a ****", wantAction: ActionMask},
+		{name: "substring is not a match", body: "kerfuffles everywhere", wantBody: "kerfuffles everywhere", wantAction: ActionNone},
+		{name: "hold keeps the word", body: "Fornax and kerfuffle", wantBody: "Fornax and ****", wantAction: ActionHold},
+		{name: "l is not i", body: "leaves fall in autumn", wantBody: "leaves fall in autumn", wantAction: ActionNone},
+		{name: "one is i", body: "epic fa1l", wantBody: "epic fa1l", wantAction: ActionReject},
+		{name: "phrase", body: "that is a BAD, idea", wantBody: "that is a BAD, idea", wantAction: ActionReject},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Apply(tt.body, rules)
+			assert.Equal(t, tt.wantBody, result.Body)
+			assert.Equal(t, tt.wantAction, result.Action)
+		})
+	}
+}
+
+func TestNormalizeTerm(t *testing.T) {
+	term, err := NormalizeTerm("  Bad   IDEA! ")
+	require.NoError(t, err, "Expected term to be valid")
+	assert.Equal(t, "bad idea", term)
+
+	_, err = NormalizeTerm("?!")
+	assert.Error(t, err, "Expected error for term without letters")
+}
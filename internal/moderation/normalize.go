@@ -0,0 +1,103 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize folds text into the form rules are matched in: the text is
+// put in NFKC form, so fullwidth, circled and mathematical letters,
+// ligatures and super- and subscripts become their plain equivalents,
+// zero-width characters disappear, letters are lowercased with diacritics
+// and common homoglyphs removed, and leetspeak digits and symbols become
+// the letters they stand for.
+func Normalize(text string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKC.String(text) {
+		for _, folded := range foldRune(r) {
+			b.WriteRune(folded)
+		}
+	}
+	return b.String()
+}
+
+// foldRune folds a single rune. It decomposes r itself, so it gives the
+// same result whether or not r has been through NFKC already.
+func foldRune(r rune) []rune {
+	var letters []rune
+	for _, c := range norm.NFKD.String(string(r)) {
+		if isZeroWidth(c) || unicode.Is(unicode.Mn, c) {
+			continue
+		}
+		c = unicode.ToLower(c)
+		if plain, ok := plainLetters[c]; ok {
+			c = plain
+		}
+		if letter, ok := leetspeak[c]; ok {
+			c = letter
+		}
+		letters = append(letters, c)
+	}
+	return letters
+}
+
+func isZeroWidth(r rune) bool {
+	switch r {
+	case '\u00ad', '\u034f', '\u180e', '\u200b', '\u200c', '\u200d', '\u200e', '\u200f',
+		'\u2060', '\u2061', '\u2062', '\u2063', '\u2064', '\ufeff':
+		return true
+	}
+	return false
+}
+
+// compatible returns the NFKC form of r when that is a single rune, and r
+// otherwise.
+func compatible(r rune) rune {
+	s := norm.NFKC.String(string(r))
+	if c, size := utf8.DecodeRuneInString(s); size == len(s) {
+		return c
+	}
+	return r
+}
+
+// plainLetters maps Latin letters whose marks don't decompose (a stroke
+// or a dot that is part of the letter) and Cyrillic and Greek letters that
+// look like Latin ones onto plain Latin letters.
+var plainLetters = func() map[rune]rune {
+	groups := map[rune]string{
+		'a': "аα",
+		'c': "с",
+		'd': "đ",
+		'e': "еε",
+		'h': "ħһ",
+		'i': "ıіι",
+		'j': "ј",
+		'k': "кκ",
+		'l': "ŀł",
+		'n': "η",
+		'o': "øоο",
+		'p': "рρ",
+		's': "ѕ",
+		't': "ŧτ",
+		'x': "хχ",
+		'y': "уγ",
+	}
+	letters := map[rune]rune{}
+	for plain, variants := range groups {
+		for _, variant := range variants {
+			letters[variant] = plain
+		}
+	}
+	return letters
+}()
+
+// leetspeak maps digits and symbols onto the letters they stand in for. A
+// "1", "!" or "|" is read as "i"; folding the letter "l" into it as well
+// would make words like "fall" and "fail" match each other.
+var leetspeak = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+	'@': 'a', '$': 's', '!': 'i', '|': 'i', '+': 't',
+}
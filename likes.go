@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/exy63/chirpy/internal/auth"
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// withLikeStats fills in like_count and liked_by_me for a batch of chirps
+// with a single query, however many chirps the batch holds.
+func (cfg *apiConfig) withLikeStats(ctx context.Context, viewerID uuid.NullUUID, chirps []*ChirpResponse) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, len(chirps))
+	for i, chirp := range chirps {
+		chirpIDs[i] = chirp.ID
+	}
+
+	params := database.GetChirpLikeStatsParams{
+		ViewerID: viewerID,
+		ChirpIds: chirpIDs,
+	}
+	stats, err := cfg.dbQueries.GetChirpLikeStats(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	statsByChirpID := make(map[uuid.UUID]database.GetChirpLikeStatsRow, len(stats))
+	for _, stat := range stats {
+		statsByChirpID[stat.ChirpID] = stat
+	}
+	for _, chirp := range chirps {
+		stat := statsByChirpID[chirp.ID]
+		chirp.LikeCount = stat.LikeCount
+		chirp.LikedByMe = stat.LikedByMe
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) handlerLikeChirp(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if !cfg.requireVerifiedEmail(w, r, UserID) {
+		return
+	}
+
+	id := r.PathValue("id")
+	chirpID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid || !chirp.Published {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	params := database.LikeChirpParams{
+		UserID:  UserID,
+		ChirpID: chirp.ID,
+	}
+	liked, err := qtx.LikeChirp(r.Context(), params)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if liked > 0 {
+		err := notify(r.Context(), qtx, chirp.UserID, notificationLike, uuid.NullUUID{UUID: UserID, Valid: true}, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	id := r.PathValue("id")
+	chirpID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.UnlikeChirpParams{
+		UserID:  UserID,
+		ChirpID: chirpID,
+	}
+	if err := cfg.dbQueries.UnlikeChirp(r.Context(), params); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetUserLikes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	id := r.PathValue("id")
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetUserLikedChirpsParams{
+		UserID: userID,
+		Limit:  limit + 1,
+	}
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil || cursor.Direction != cursorNext {
+			respondWithError(w, http.StatusBadRequest, "cursor is invalid")
+			return
+		}
+		params.BeforeLikedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeChirpID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.dbQueries.GetUserLikedChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	hasMore := len(rows) > int(limit)
+	if hasMore {
+		rows = rows[:limit]
+	}
+
+	page := ChirpsPageResponse{Chirps: make([]ChirpResponse, len(rows))}
+	chirps := make([]*ChirpResponse, len(rows))
+	for i, row := range rows {
+		page.Chirps[i] = newChirpResponse(row.Chirp)
+		chirps[i] = &page.Chirps[i]
+	}
+	if err := cfg.enrichChirps(r.Context(), cfg.viewerID(r), chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if hasMore {
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeCursor(cursorNext, last.LikedAt, last.Chirp.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
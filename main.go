@@ -1,23 +1,40 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"sync/atomic"
 
 	"github.com/exy63/chirpy/internal/database"
+	"github.com/exy63/chirpy/internal/events"
+	"github.com/exy63/chirpy/internal/mail"
+	"github.com/exy63/chirpy/internal/storage"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
+	db             *sql.DB
 	dbQueries      *database.Queries
 	platform       string
 	jwtSecret      string
 	polkaKey       string
+	adminKey       string
+	// chirpyRedMaxChirpLength replaces maxChirpLength for Chirpy Red users.
+	chirpyRedMaxChirpLength int
+	mediaStorage            storage.Storage
+	chirpEvents             *events.Hub
+	mailer                  mail.Sender
+	// publicBaseURL is where clients reach the API, for links that leave
+	// the server such as feed IDs and verification emails.
+	publicBaseURL string
 }
 
 func main() {
@@ -35,23 +52,137 @@ func main() {
 	platform := os.Getenv("PLATFORM")
 	jwtSecret := os.Getenv("JWT_SECRET")
 	polkaKey := os.Getenv("POLKA_KEY")
+	adminKey := os.Getenv("ADMIN_API_KEY")
 
-	apiCfg := apiConfig{fileserverHits: atomic.Int32{}, dbQueries: dbQueries, platform: platform, jwtSecret: jwtSecret, polkaKey: polkaKey}
+	chirpyRedMaxChirpLength := defaultChirpyRedMaxChirpLength
+	if limit := os.Getenv("CHIRPY_RED_MAX_CHIRP_LENGTH"); limit != "" {
+		chirpyRedMaxChirpLength, err = strconv.Atoi(limit)
+		if err != nil || chirpyRedMaxChirpLength < maxChirpLength {
+			log.Fatal("CHIRPY_RED_MAX_CHIRP_LENGTH must be a number of at least 140")
+		}
+	}
+
+	// Links are never built from the Host header, which clients control.
+	publicBaseURL := strings.TrimSuffix(os.Getenv("PUBLIC_BASE_URL"), "/")
+	if publicBaseURL == "" {
+		publicBaseURL = "http://localhost:" + port
+	}
+	if parsedBaseURL, err := url.Parse(publicBaseURL); err != nil || (parsedBaseURL.Scheme != "http" && parsedBaseURL.Scheme != "https") || parsedBaseURL.Host == "" {
+		log.Fatal("PUBLIC_BASE_URL must be an http or https URL")
+	}
+
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "./media"
+	}
+	mediaStorage, err := storage.NewLocalStorage(mediaDir, "/media/")
+	if err != nil {
+		log.Fatal("Could not create the media directory")
+	}
+
+	// Without an SMTP relay, mail is written to an outbox directory instead.
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "Chirpy <no-reply@localhost>"
+	}
+	var mailer mail.Sender
+	if smtpAddr := os.Getenv("SMTP_ADDR"); smtpAddr != "" {
+		mailer, err = mail.NewSMTPSender(smtpAddr, mailFrom, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+		if err != nil {
+			log.Fatal("SMTP_ADDR must be host:port and MAIL_FROM a valid address")
+		}
+	} else {
+		outboxDir := os.Getenv("MAIL_OUTBOX_DIR")
+		if outboxDir == "" {
+			outboxDir = "./outbox"
+		}
+		mailer, err = mail.NewOutbox(outboxDir, mailFrom)
+		if err != nil {
+			log.Fatal("Could not create the mail outbox directory")
+		}
+	}
+
+	apiCfg := apiConfig{fileserverHits: atomic.Int32{}, db: db, dbQueries: dbQueries, platform: platform, jwtSecret: jwtSecret, polkaKey: polkaKey, adminKey: adminKey, chirpyRedMaxChirpLength: chirpyRedMaxChirpLength, mediaStorage: mediaStorage, chirpEvents: events.NewHub(chirpEventHistory), mailer: mailer, publicBaseURL: publicBaseURL}
 	mux := http.NewServeMux()
 	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot)))))
+	mux.Handle("GET /media/", http.StripPrefix("/media", mediaStorage))
 	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
 	mux.HandleFunc("POST /admin/reset", apiCfg.handlerResetUsers)
+	mux.HandleFunc("GET /admin/moderation/rules", apiCfg.handlerGetModerationRules)
+	mux.HandleFunc("POST /admin/moderation/rules", apiCfg.handlerCreateModerationRule)
+	mux.HandleFunc("PUT /admin/moderation/rules/{id}", apiCfg.handlerUpdateModerationRule)
+	mux.HandleFunc("DELETE /admin/moderation/rules/{id}", apiCfg.handlerDeleteModerationRule)
+	mux.HandleFunc("GET /admin/moderation/queue", apiCfg.handlerGetModerationQueue)
+	mux.HandleFunc("POST /admin/moderation/queue/{id}/approve", apiCfg.handlerApproveHeldChirp)
+	mux.HandleFunc("POST /admin/moderation/queue/{id}/reject", apiCfg.handlerRejectHeldChirp)
 	mux.HandleFunc("GET /api/healthz", handlerReadiness)
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerCreateChirp)
+	mux.Handle("POST /api/chirps", apiCfg.middlewareIdempotency(http.HandlerFunc(apiCfg.handlerCreateChirp)))
 	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetChirps)
+	mux.HandleFunc("GET /api/chirps/search", apiCfg.handlerSearchChirps)
+	mux.HandleFunc("GET /api/chirps/stream", apiCfg.handlerStreamChirps)
+	mux.HandleFunc("GET /api/chirps/feed.atom", apiCfg.handlerGetChirpsAtomFeed)
+	mux.HandleFunc("GET /api/chirps/feed.rss", apiCfg.handlerGetChirpsRSSFeed)
 	mux.HandleFunc("GET /api/chirps/{id}", apiCfg.handlerGetChirp)
+	mux.HandleFunc("PUT /api/chirps/{id}", apiCfg.handlerUpdateChirp)
 	mux.HandleFunc("DELETE /api/chirps/{id}", apiCfg.handlerDeleteChirp)
-	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
+	mux.HandleFunc("GET /api/chirps/{id}/revisions", apiCfg.handlerGetChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{id}/thread", apiCfg.handlerGetChirpThread)
+	mux.HandleFunc("POST /api/chirps/{id}/likes", apiCfg.handlerLikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{id}/likes", apiCfg.handlerUnlikeChirp)
+	mux.HandleFunc("GET /api/timeline", apiCfg.handlerGetTimeline)
+	mux.HandleFunc("GET /api/scheduled_chirps", apiCfg.handlerGetScheduledChirps)
+	mux.HandleFunc("GET /api/scheduled_chirps/{id}", apiCfg.handlerGetScheduledChirp)
+	mux.HandleFunc("PUT /api/scheduled_chirps/{id}", apiCfg.handlerUpdateScheduledChirp)
+	mux.HandleFunc("DELETE /api/scheduled_chirps/{id}", apiCfg.handlerDeleteScheduledChirp)
+	mux.HandleFunc("POST /api/conversations", apiCfg.handlerCreateConversation)
+	mux.HandleFunc("GET /api/conversations", apiCfg.handlerGetConversations)
+	mux.HandleFunc("GET /api/conversations/unread_count", apiCfg.handlerGetUnreadCount)
+	mux.HandleFunc("GET /api/conversations/{id}", apiCfg.handlerGetConversation)
+	mux.HandleFunc("POST /api/conversations/{id}/read", apiCfg.handlerMarkConversationRead)
+	mux.HandleFunc("POST /api/conversations/{id}/messages", apiCfg.handlerSendDirectMessage)
+	mux.HandleFunc("GET /api/conversations/{id}/messages", apiCfg.handlerGetDirectMessages)
+	mux.HandleFunc("PUT /api/conversations/{id}/messages/{messageID}", apiCfg.handlerUpdateDirectMessage)
+	mux.HandleFunc("DELETE /api/conversations/{id}/messages/{messageID}", apiCfg.handlerDeleteDirectMessage)
+	mux.HandleFunc("GET /api/notifications", apiCfg.handlerGetNotifications)
+	mux.HandleFunc("GET /api/notifications/unread_count", apiCfg.handlerGetUnreadNotificationCount)
+	mux.HandleFunc("POST /api/notifications/read_all", apiCfg.handlerMarkAllNotificationsRead)
+	mux.HandleFunc("POST /api/notifications/{id}/read", apiCfg.handlerMarkNotificationRead)
+	mux.HandleFunc("GET /api/notifications/preferences", apiCfg.handlerGetNotificationPreferences)
+	mux.HandleFunc("PUT /api/notifications/preferences", apiCfg.handlerUpdateNotificationPreferences)
+	mux.HandleFunc("POST /api/media", apiCfg.handlerUploadMedia)
+	mux.HandleFunc("GET /api/hashtags/trending", apiCfg.handlerGetTrendingHashtags)
+	mux.HandleFunc("GET /api/hashtags/{tag}", apiCfg.handlerGetHashtagChirps)
+	mux.Handle("POST /api/users", apiCfg.middlewareIdempotency(http.HandlerFunc(apiCfg.handlerCreateUser)))
 	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateUser)
+	mux.HandleFunc("GET /api/users/verify", apiCfg.handlerVerifyEmail)
+	mux.HandleFunc("POST /api/users/verify/resend", apiCfg.handlerResendEmailVerification)
+	mux.HandleFunc("GET /api/users/{id}", apiCfg.handlerGetUser)
+	mux.HandleFunc("GET /api/users/{id}/likes", apiCfg.handlerGetUserLikes)
+	mux.HandleFunc("GET /api/users/{lookup}/{handle}", apiCfg.handlerGetUserByHandle)
+	mux.HandleFunc("POST /api/users/{id}/follow", apiCfg.handlerFollowUser)
+	mux.HandleFunc("DELETE /api/users/{id}/follow", apiCfg.handlerUnfollowUser)
+	mux.HandleFunc("GET /api/users/{id}/followers", apiCfg.handlerGetFollowers)
+	mux.HandleFunc("GET /api/users/{id}/following", apiCfg.handlerGetFollowing)
+	mux.HandleFunc("POST /api/users/{id}/block", apiCfg.handlerBlockUser)
+	mux.HandleFunc("DELETE /api/users/{id}/block", apiCfg.handlerUnblockUser)
+	mux.HandleFunc("POST /api/users/{id}/mute", apiCfg.handlerMuteUser)
+	mux.HandleFunc("DELETE /api/users/{id}/mute", apiCfg.handlerUnmuteUser)
+	mux.HandleFunc("GET /api/users/{id}/feed.atom", apiCfg.handlerGetUserAtomFeed)
+	mux.HandleFunc("GET /api/users/{id}/feed.rss", apiCfg.handlerGetUserRSSFeed)
+	mux.HandleFunc("GET /api/users/me/blocks", apiCfg.handlerGetBlocks)
+	mux.HandleFunc("GET /api/users/me/mutes", apiCfg.handlerGetMutes)
+	mux.HandleFunc("GET /api/users/me", apiCfg.handlerGetMe)
+	mux.HandleFunc("PATCH /api/users/me", apiCfg.handlerPatchMe)
+	mux.HandleFunc("GET /api/users/me/export", apiCfg.handlerExportUser)
+	mux.HandleFunc("POST /api/users/me/import", apiCfg.handlerImportUser)
 	mux.HandleFunc("POST /api/login", apiCfg.handlerLoginUser)
 	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefreshToken)
 	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevokeToken)
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerUpgradeUser)
+	mux.Handle("POST /api/polka/webhooks", apiCfg.middlewareIdempotency(http.HandlerFunc(apiCfg.handlerUpgradeUser)))
+
+	go apiCfg.runScheduledPublisher(context.Background())
+	go apiCfg.runIdempotencyKeyCleanup(context.Background())
+	go apiCfg.runChirpEventListener(context.Background(), dbURL)
 
 	srv := &http.Server{
 		Addr:    ":" + port,
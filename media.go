@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/exy63/chirpy/internal/auth"
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/exy63/chirpy/internal/media"
+	"github.com/google/uuid"
+)
+
+const (
+	maxChirpMedia      = 4
+	maxAltTextLength   = 1000
+	multipartOverhead  = 1 << 20
+	mediaFormFieldName = "file"
+)
+
+type MediaResponse struct {
+	ID              uuid.UUID `json:"id"`
+	ContentType     string    `json:"content_type"`
+	URL             string    `json:"url"`
+	Width           int32     `json:"width"`
+	Height          int32     `json:"height"`
+	ThumbnailURL    string    `json:"thumbnail_url"`
+	ThumbnailWidth  int32     `json:"thumbnail_width"`
+	ThumbnailHeight int32     `json:"thumbnail_height"`
+	AltText         string    `json:"alt_text"`
+}
+
+func (cfg *apiConfig) newMediaResponse(attachment database.MediaAttachment) MediaResponse {
+	return MediaResponse{
+		ID:              attachment.ID,
+		ContentType:     attachment.ContentType,
+		URL:             cfg.mediaStorage.URL(attachment.StorageKey),
+		Width:           attachment.Width,
+		Height:          attachment.Height,
+		ThumbnailURL:    cfg.mediaStorage.URL(attachment.ThumbnailKey),
+		ThumbnailWidth:  attachment.ThumbnailWidth,
+		ThumbnailHeight: attachment.ThumbnailHeight,
+		AltText:         attachment.AltText,
+	}
+}
+
+func (cfg *apiConfig) handlerUploadMedia(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if !cfg.requireVerifiedEmail(w, r, UserID) {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, media.MaxUploadBytes+multipartOverhead)
+	file, _, err := r.FormFile(mediaFormFieldName)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, media.ErrTooLarge.Error())
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "A multipart file field named \"file\" is required")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadBytes+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	processed, err := media.Process(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, media.ErrTooLarge):
+			respondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, media.ErrUnsupportedType):
+			respondWithError(w, http.StatusUnsupportedMediaType, err.Error())
+		default:
+			respondWithError(w, http.StatusBadRequest, "Could not decode image")
+		}
+		return
+	}
+
+	keyPrefix := uuid.NewString()
+	storageKey := keyPrefix + processed.Original.Extension
+	thumbnailKey := keyPrefix + "_thumb" + processed.Thumbnail.Extension
+
+	if err := cfg.mediaStorage.Put(r.Context(), storageKey, processed.Original.Data); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store media")
+		return
+	}
+	if err := cfg.mediaStorage.Put(r.Context(), thumbnailKey, processed.Thumbnail.Data); err != nil {
+		cfg.deleteStoredMedia(r.Context(), storageKey)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store media")
+		return
+	}
+
+	params := database.CreateMediaAttachmentParams{
+		UserID:          UserID,
+		ContentType:     processed.Original.ContentType,
+		StorageKey:      storageKey,
+		Width:           int32(processed.Original.Width),
+		Height:          int32(processed.Original.Height),
+		ThumbnailKey:    thumbnailKey,
+		ThumbnailWidth:  int32(processed.Thumbnail.Width),
+		ThumbnailHeight: int32(processed.Thumbnail.Height),
+		SizeBytes:       int32(len(processed.Original.Data)),
+	}
+	attachment, err := cfg.dbQueries.CreateMediaAttachment(r.Context(), params)
+	if err != nil {
+		cfg.deleteStoredMedia(r.Context(), storageKey, thumbnailKey)
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, cfg.newMediaResponse(attachment))
+}
+
+// withMedia fills in the media attached to a batch of chirps with a single
+// query.
+func (cfg *apiConfig) withMedia(ctx context.Context, chirps []*ChirpResponse) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, len(chirps))
+	for i, chirp := range chirps {
+		chirpIDs[i] = chirp.ID
+	}
+
+	attachments, err := cfg.dbQueries.GetMediaForChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	mediaByChirpID := make(map[uuid.UUID][]MediaResponse)
+	for _, attachment := range attachments {
+		mediaByChirpID[attachment.ChirpID.UUID] = append(mediaByChirpID[attachment.ChirpID.UUID], cfg.newMediaResponse(attachment))
+	}
+	for _, chirp := range chirps {
+		chirp.Media = mediaByChirpID[chirp.ID]
+	}
+
+	return nil
+}
+
+// deleteStoredMedia removes files whose database rows are already gone.
+// Failures only leave orphaned files behind, so they are logged, not
+// returned.
+func (cfg *apiConfig) deleteStoredMedia(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := cfg.mediaStorage.Delete(ctx, key); err != nil {
+			log.Printf("Couldn't delete stored media %s: %v", key, err)
+		}
+	}
+}
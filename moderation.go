@@ -0,0 +1,407 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/exy63/chirpy/internal/moderation"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	moderationApproved = "approved"
+	moderationHeld     = "held"
+	moderationRejected = "rejected"
+)
+
+// cleanedBody is a chirp body after moderation. OriginalBody is only set
+// when masking changed what is served, so moderators can still see what
+// was written.
+type cleanedBody struct {
+	Body             string
+	OriginalBody     sql.NullString
+	ModerationStatus string
+}
+
+type ModerationRuleResponse struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Term      string    `json:"term"`
+	Action    string    `json:"action"`
+}
+
+type HeldChirpResponse struct {
+	ChirpResponse
+	OriginalBody string `json:"original_body"`
+}
+
+type HeldChirpsPageResponse struct {
+	Chirps     []HeldChirpResponse `json:"chirps"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) moderationRules(ctx context.Context) ([]moderation.Rule, error) {
+	dbRules, err := cfg.dbQueries.GetModerationRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]moderation.Rule, len(dbRules))
+	for i, rule := range dbRules {
+		rules[i] = moderation.Rule{Term: rule.Term, Action: moderation.Action(rule.Action)}
+	}
+	return rules, nil
+}
+
+func getCleanedBody(msg string, policy chirpPolicy) (cleanedBody, error) {
+	if length := chirpLength(msg); length > policy.MaxLength {
+		return cleanedBody{}, chirpTooLongError{Length: length, Limit: policy.MaxLength}
+	}
+
+	result := moderation.Apply(msg, policy.Rules)
+	if result.Action == moderation.ActionReject {
+		return cleanedBody{}, errors.New("chirp contains a term that is not allowed")
+	}
+
+	cleaned := cleanedBody{Body: result.Body, ModerationStatus: moderationApproved}
+	if result.Body != msg {
+		cleaned.OriginalBody = sql.NullString{String: msg, Valid: true}
+	}
+	if result.Action == moderation.ActionHold {
+		cleaned.ModerationStatus = moderationHeld
+	}
+	return cleaned, nil
+}
+
+func newModerationRuleResponse(rule database.ModerationRule) ModerationRuleResponse {
+	return ModerationRuleResponse{
+		ID:        rule.ID,
+		CreatedAt: rule.CreatedAt,
+		UpdatedAt: rule.UpdatedAt,
+		Term:      rule.Term,
+		Action:    rule.Action,
+	}
+}
+
+// parseModerationRule validates a rule as submitted by an admin. Terms are
+// stored lowercased with their whitespace collapsed, so the same term
+// cannot be added twice with different spacing or casing.
+func parseModerationRule(r *http.Request) (term string, action moderation.Action, err error) {
+	type Request struct {
+		Term   string `json:"term"`
+		Action string `json:"action"`
+	}
+	var req Request
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		return "", "", errors.New("Invalid request body format")
+	}
+
+	term = strings.Join(strings.Fields(strings.ToLower(req.Term)), " ")
+	if _, err := moderation.NormalizeTerm(term); err != nil {
+		return "", "", err
+	}
+	action, err = moderation.ParseAction(req.Action)
+	if err != nil {
+		return "", "", err
+	}
+	return term, action, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func (cfg *apiConfig) handlerGetModerationRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin API key required")
+		return
+	}
+
+	rules, err := cfg.dbQueries.GetModerationRules(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ruleResponses := make([]ModerationRuleResponse, len(rules))
+	for i, rule := range rules {
+		ruleResponses[i] = newModerationRuleResponse(rule)
+	}
+
+	respondWithJSON(w, http.StatusOK, ruleResponses)
+}
+
+func (cfg *apiConfig) handlerCreateModerationRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin API key required")
+		return
+	}
+
+	term, action, err := parseModerationRule(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.CreateModerationRuleParams{
+		Term:   term,
+		Action: string(action),
+	}
+	rule, err := cfg.dbQueries.CreateModerationRule(r.Context(), params)
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "A rule for this term already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newModerationRuleResponse(rule))
+}
+
+func (cfg *apiConfig) handlerUpdateModerationRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin API key required")
+		return
+	}
+
+	id := r.PathValue("id")
+	ruleID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	term, action, err := parseModerationRule(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.UpdateModerationRuleParams{
+		ID:     ruleID,
+		Term:   term,
+		Action: string(action),
+	}
+	rule, err := cfg.dbQueries.UpdateModerationRule(r.Context(), params)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Rule not found")
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "A rule for this term already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newModerationRuleResponse(rule))
+}
+
+func (cfg *apiConfig) handlerDeleteModerationRule(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	if !cfg.isAdmin(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	id := r.PathValue("id")
+	ruleID, err := uuid.Parse(id)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteModerationRule(r.Context(), ruleID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetModerationQueue lists held chirps oldest first, with the body
+// as written next to the body that would be served.
+func (cfg *apiConfig) handlerGetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin API key required")
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetHeldChirpsParams{
+		Limit: limit + 1,
+	}
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil || cursor.Direction != cursorNext {
+			respondWithError(w, http.StatusBadRequest, "cursor is invalid")
+			return
+		}
+		params.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	chirps, err := cfg.dbQueries.GetHeldChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	hasMore := len(chirps) > int(limit)
+	if hasMore {
+		chirps = chirps[:limit]
+	}
+
+	page := HeldChirpsPageResponse{Chirps: make([]HeldChirpResponse, len(chirps))}
+	batch := make([]*ChirpResponse, len(chirps))
+	for i, chirp := range chirps {
+		page.Chirps[i] = HeldChirpResponse{
+			ChirpResponse: newChirpResponse(chirp),
+			OriginalBody:  chirp.Body,
+		}
+		if chirp.OriginalBody.Valid {
+			page.Chirps[i].OriginalBody = chirp.OriginalBody.String
+		}
+		batch[i] = &page.Chirps[i].ChirpResponse
+	}
+	if err := cfg.enrichChirps(r.Context(), uuid.NullUUID{}, batch); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if hasMore {
+		last := chirps[len(chirps)-1]
+		page.NextCursor = encodeCursor(cursorNext, last.CreatedAt, last.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func (cfg *apiConfig) handlerApproveHeldChirp(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin API key required")
+		return
+	}
+
+	id := r.PathValue("id")
+	chirpID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := qtx.ApproveHeldChirp(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Held chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Approved chirps that are still scheduled get their hashtags when the
+	// publisher releases them.
+	if chirp.Published {
+		if err := syncChirpHashtags(r.Context(), qtx, chirp); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	// A held edit was already announced when the chirp was first published.
+	if chirp.Published && !chirp.EditedAt.Valid {
+		if err := notifyChirpPublished(r.Context(), qtx, chirp); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if chirp.Published {
+		cfg.broadcastChirpCreated(r.Context(), chirp)
+	}
+
+	respondWithJSON(w, http.StatusOK, newChirpResponse(chirp))
+}
+
+func (cfg *apiConfig) handlerRejectHeldChirp(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin API key required")
+		return
+	}
+
+	id := r.PathValue("id")
+	chirpID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	chirp, err := cfg.dbQueries.RejectHeldChirp(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Held chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newChirpResponse(chirp))
+}
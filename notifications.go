@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/exy63/chirpy/internal/auth"
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	notificationFollow    = "follow"
+	notificationMention   = "mention"
+	notificationReply     = "reply"
+	notificationLike      = "like"
+	notificationChirpyRed = "chirpy_red"
+)
+
+// maxMentionsPerChirp caps the mention notifications a single chirp sends.
+const maxMentionsPerChirp = 10
+
+var notificationTypes = []string{
+	notificationFollow,
+	notificationMention,
+	notificationReply,
+	notificationLike,
+	notificationChirpyRed,
+}
+
+type NotificationResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty"`
+	ChirpID   *uuid.UUID `json:"chirp_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	Read      bool       `json:"read"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+type NotificationsPageResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	NextCursor    string                 `json:"next_cursor,omitempty"`
+}
+
+// NotificationPreferencesResponse maps every notification type to whether
+// the user receives it.
+type NotificationPreferencesResponse map[string]bool
+
+func newNotificationResponse(notification database.Notification) NotificationResponse {
+	notificationResponse := NotificationResponse{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		CreatedAt: notification.CreatedAt,
+		Read:      notification.ReadAt.Valid,
+	}
+	if notification.ActorID.Valid {
+		notificationResponse.ActorID = &notification.ActorID.UUID
+	}
+	if notification.ChirpID.Valid {
+		notificationResponse.ChirpID = &notification.ChirpID.UUID
+	}
+	if notification.ReadAt.Valid {
+		notificationResponse.ReadAt = &notification.ReadAt.Time
+	}
+	return notificationResponse
+}
+
+// notify records a notification for userID. It takes the queries of the
+// transaction making the change, so the notification commits or rolls back
+// with it. Nothing is written for users' own actions, for actors the user
+// has blocked or muted, or for types the user has turned off.
+func notify(ctx context.Context, q *database.Queries, userID uuid.UUID, notificationType string, actorID, chirpID uuid.NullUUID) error {
+	return q.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID:  userID,
+		Type:    notificationType,
+		ActorID: actorID,
+		ChirpID: chirpID,
+	})
+}
+
+// notifyChirpPublished sends the notifications a chirp triggers when it
+// becomes visible. Like syncChirpHashtags, it runs in the transaction that
+// publishes the chirp.
+func notifyChirpPublished(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	actorID := uuid.NullUUID{UUID: chirp.UserID, Valid: true}
+	chirpID := uuid.NullUUID{UUID: chirp.ID, Valid: true}
+
+	// A reply that also mentions the parent's author only notifies them once.
+	var repliedToID uuid.UUID
+	if chirp.ParentID.Valid {
+		parent, err := q.GetChirp(ctx, chirp.ParentID.UUID)
+		if err != nil {
+			return err
+		}
+		repliedToID = parent.UserID
+		if err := notify(ctx, q, parent.UserID, notificationReply, actorID, chirpID); err != nil {
+			return err
+		}
+	}
+
+	mentioned, err := mentionedUsers(ctx, q, chirp.Body)
+	if err != nil {
+		return err
+	}
+	for _, user := range mentioned {
+		if user.ID == repliedToID {
+			continue
+		}
+		if err := notify(ctx, q, user.ID, notificationMention, actorID, chirpID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) handlerGetNotifications(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetNotificationsParams{
+		UserID: UserID,
+		Limit:  limit + 1,
+	}
+	switch query.Get("unread") {
+	case "", "false":
+	case "true":
+		params.UnreadOnly = true
+	default:
+		respondWithError(w, http.StatusBadRequest, "unread must be true or false")
+		return
+	}
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil || cursor.Direction != cursorNext {
+			respondWithError(w, http.StatusBadRequest, "cursor is invalid")
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	notifications, err := cfg.dbQueries.GetNotifications(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	hasMore := len(notifications) > int(limit)
+	if hasMore {
+		notifications = notifications[:limit]
+	}
+
+	page := NotificationsPageResponse{Notifications: make([]NotificationResponse, len(notifications))}
+	for i, notification := range notifications {
+		page.Notifications[i] = newNotificationResponse(notification)
+	}
+	if hasMore {
+		last := notifications[len(notifications)-1]
+		page.NextCursor = encodeCursor(cursorNext, last.CreatedAt, last.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func (cfg *apiConfig) handlerGetUnreadNotificationCount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	unreadCount, err := cfg.dbQueries.CountUnreadNotifications(r.Context(), UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, UnreadCountResponse{UnreadCount: unreadCount})
+}
+
+func (cfg *apiConfig) handlerMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	id := r.PathValue("id")
+	notificationID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.MarkNotificationReadParams{
+		ID:     notificationID,
+		UserID: UserID,
+	}
+	updated, err := cfg.dbQueries.MarkNotificationRead(r.Context(), params)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if updated == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerMarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if err := cfg.dbQueries.MarkAllNotificationsRead(r.Context(), UserID); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) notificationPreferences(ctx context.Context, userID uuid.UUID) (NotificationPreferencesResponse, error) {
+	stored, err := cfg.dbQueries.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make(NotificationPreferencesResponse, len(notificationTypes))
+	for _, notificationType := range notificationTypes {
+		preferences[notificationType] = true
+	}
+	for _, preference := range stored {
+		preferences[preference.Type] = preference.Enabled
+	}
+	return preferences, nil
+}
+
+func (cfg *apiConfig) handlerGetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	preferences, err := cfg.notificationPreferences(r.Context(), UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, preferences)
+}
+
+// handlerUpdateNotificationPreferences turns notification types on or off.
+// Types missing from the body keep their current setting.
+func (cfg *apiConfig) handlerUpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req map[string]bool
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body format")
+		return
+	}
+
+	for notificationType := range req {
+		if !slices.Contains(notificationTypes, notificationType) {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("%q is not a notification type", notificationType))
+			return
+		}
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	for notificationType, enabled := range req {
+		params := database.SetNotificationPreferenceParams{
+			UserID:  UserID,
+			Type:    notificationType,
+			Enabled: enabled,
+		}
+		if err := qtx.SetNotificationPreference(r.Context(), params); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	preferences, err := cfg.notificationPreferences(r.Context(), UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, preferences)
+}
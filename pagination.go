@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+type cursorDirection string
+
+const (
+	cursorNext cursorDirection = "next"
+	cursorPrev cursorDirection = "prev"
+)
+
+// pageCursor points at a single row in a keyset-ordered listing. Direction
+// says whether the client is paging forwards (rows after the position in
+// the requested order) or backwards (rows before it).
+type pageCursor struct {
+	Direction cursorDirection
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func encodeCursor(direction cursorDirection, createdAt time.Time, id uuid.UUID) string {
+	raw := string(direction) + "|" + createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (pageCursor, error) {
+	invalidCursor := errors.New("cursor is invalid")
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return pageCursor{}, invalidCursor
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return pageCursor{}, invalidCursor
+	}
+
+	direction := cursorDirection(parts[0])
+	if direction != cursorNext && direction != cursorPrev {
+		return pageCursor{}, invalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return pageCursor{}, invalidCursor
+	}
+	id, err := uuid.Parse(parts[2])
+	if err != nil {
+		return pageCursor{}, invalidCursor
+	}
+
+	return pageCursor{Direction: direction, CreatedAt: createdAt, ID: id}, nil
+}
+
+// encodeOffsetCursor and decodeOffsetCursor are used by listings, such as
+// ranked search results, whose order has no stable keyset to page on.
+func encodeOffsetCursor(offset int32) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset|" + strconv.Itoa(int(offset))))
+}
+
+func decodeOffsetCursor(cursor string) (int32, error) {
+	invalidCursor := errors.New("cursor is invalid")
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, invalidCursor
+	}
+
+	value, found := strings.CutPrefix(string(raw), "offset|")
+	if !found {
+		return 0, invalidCursor
+	}
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, invalidCursor
+	}
+
+	return int32(offset), nil
+}
+
+func parsePageLimit(limit string) (int32, error) {
+	if limit == "" {
+		return defaultPageLimit, nil
+	}
+
+	parsedLimit, err := strconv.Atoi(limit)
+	if err != nil || parsedLimit < 1 || parsedLimit > maxPageLimit {
+		return 0, errors.New("limit must be a number between 1 and " + strconv.Itoa(maxPageLimit))
+	}
+
+	return int32(parsedLimit), nil
+}
+
+// pagesForward reports whether a request is paging in the requested sort
+// order. The first page, requested without a cursor, always is.
+func pagesForward(cursor *pageCursor) bool {
+	return cursor == nil || cursor.Direction == cursorNext
+}
+
+// pageCursors builds the next and previous cursors for a page whose rows run
+// from first to last, given the cursor that was used to fetch it and whether
+// more rows exist in the direction of travel.
+func pageCursors(cursor *pageCursor, hasMore bool, firstCreatedAt time.Time, firstID uuid.UUID, lastCreatedAt time.Time, lastID uuid.UUID) (next string, prev string) {
+	forward := pagesForward(cursor)
+	if hasMore || !forward {
+		next = encodeCursor(cursorNext, lastCreatedAt, lastID)
+	}
+	if (forward && cursor != nil) || (!forward && hasMore) {
+		prev = encodeCursor(cursorPrev, firstCreatedAt, firstID)
+	}
+	return next, prev
+}
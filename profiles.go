@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/exy63/chirpy/internal/graphemes"
+	"github.com/exy63/chirpy/internal/handles"
+	"github.com/google/uuid"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxWebsiteLength     = 200
+)
+
+// AuthorResponse is the public face of a chirp's author.
+type AuthorResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Handle      string    `json:"handle"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+}
+
+func validateDisplayName(displayName string) error {
+	if graphemes.Count(displayName) > maxDisplayNameLength {
+		return errors.New("display_name must be at most 50 characters")
+	}
+	return nil
+}
+
+func validateBio(bio string) error {
+	if graphemes.Count(bio) > maxBioLength {
+		return errors.New("bio must be at most 160 characters")
+	}
+	return nil
+}
+
+// validateWebsite accepts an empty website or an absolute http or https URL.
+func validateWebsite(website string) error {
+	if website == "" {
+		return nil
+	}
+	if len(website) > maxWebsiteLength {
+		return errors.New("website must be at most 200 characters")
+	}
+	parsed, err := url.Parse(website)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("website must be an http or https URL")
+	}
+	return nil
+}
+
+// avatarURL resolves a user's avatar to the URL it is served from, or ""
+// when they have none.
+func (cfg *apiConfig) avatarURL(ctx context.Context, avatarMediaID uuid.NullUUID) (string, error) {
+	if !avatarMediaID.Valid {
+		return "", nil
+	}
+	attachment, err := cfg.dbQueries.GetMediaAttachment(ctx, avatarMediaID.UUID)
+	if err != nil {
+		return "", err
+	}
+	return cfg.mediaStorage.URL(attachment.StorageKey), nil
+}
+
+func (cfg *apiConfig) newUserResponse(ctx context.Context, user database.User) (UserResponse, error) {
+	avatarURL, err := cfg.avatarURL(ctx, user.AvatarMediaID)
+	if err != nil {
+		return UserResponse{}, err
+	}
+	userResponse := UserResponse{
+		ID:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		IsChirpyRed:   hasChirpyRed(user),
+		Handle:        user.Handle,
+		DisplayName:   user.DisplayName,
+		Bio:           user.Bio,
+		Website:       user.Website,
+		AvatarURL:     avatarURL,
+	}
+	if user.IsChirpyRed && user.ChirpyRedExpiresAt.Valid {
+		userResponse.ChirpyRedExpiresAt = &user.ChirpyRedExpiresAt.Time
+	}
+	return userResponse, nil
+}
+
+// newPublicUserResponse builds the profile anyone may see. It never
+// includes the email address.
+func (cfg *apiConfig) newPublicUserResponse(ctx context.Context, user database.User, viewerID uuid.NullUUID) (PublicUserResponse, error) {
+	avatarURL, err := cfg.avatarURL(ctx, user.AvatarMediaID)
+	if err != nil {
+		return PublicUserResponse{}, err
+	}
+
+	params := database.GetFollowStatsParams{
+		UserID:   user.ID,
+		ViewerID: viewerID,
+	}
+	stats, err := cfg.dbQueries.GetFollowStats(ctx, params)
+	if err != nil {
+		return PublicUserResponse{}, err
+	}
+
+	return PublicUserResponse{
+		ID:             user.ID,
+		CreatedAt:      user.CreatedAt,
+		IsChirpyRed:    hasChirpyRed(user),
+		Handle:         user.Handle,
+		DisplayName:    user.DisplayName,
+		Bio:            user.Bio,
+		Website:        user.Website,
+		AvatarURL:      avatarURL,
+		FollowerCount:  stats.FollowerCount,
+		FollowingCount: stats.FollowingCount,
+		FollowedByMe:   stats.FollowedByMe,
+	}, nil
+}
+
+// withAuthors fills in the author of a batch of chirps with one query.
+func (cfg *apiConfig) withAuthors(ctx context.Context, chirps []*ChirpResponse) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		userID, err := uuid.Parse(chirp.UserID)
+		if err != nil {
+			return err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	summaries, err := cfg.dbQueries.GetUserSummaries(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	authorsByID := make(map[string]*AuthorResponse, len(summaries))
+	for _, summary := range summaries {
+		author := &AuthorResponse{
+			ID:          summary.ID,
+			Handle:      summary.Handle,
+			DisplayName: summary.DisplayName,
+		}
+		if summary.AvatarKey.Valid {
+			author.AvatarURL = cfg.mediaStorage.URL(summary.AvatarKey.String)
+		}
+		authorsByID[summary.ID.String()] = author
+	}
+	for _, chirp := range chirps {
+		chirp.Author = authorsByID[chirp.UserID]
+	}
+
+	return nil
+}
+
+// handlerGetUserByHandle serves GET /api/users/by-handle/{handle}. That
+// pattern would conflict with GET /api/users/{id}/likes and its siblings,
+// so it is routed as /api/users/{lookup}/{handle} and any other {lookup}
+// falls through to a 404.
+func (cfg *apiConfig) handlerGetUserByHandle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	if r.PathValue("lookup") != "by-handle" {
+		respondWithError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByHandle(r.Context(), r.PathValue("handle"))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	userResponse, err := cfg.newPublicUserResponse(r.Context(), user, cfg.viewerID(r))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, userResponse)
+}
+
+// mentionedUsers resolves the handles mentioned in body to users.
+func mentionedUsers(ctx context.Context, q *database.Queries, body string) ([]database.User, error) {
+	mentions := handles.Mentions(body)
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+	if len(mentions) > maxMentionsPerChirp {
+		mentions = mentions[:maxMentionsPerChirp]
+	}
+	return q.GetUsersByHandles(ctx, mentions)
+}
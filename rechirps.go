@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// resolveSharedChirp loads the chirp a rechirp or quote chirp will point at.
+// Sharing a rechirp shares the chirp it reposts, so references always lead
+// straight to an original.
+func (cfg *apiConfig) resolveSharedChirp(ctx context.Context, chirpID uuid.UUID) (database.Chirp, error) {
+	chirp, err := cfg.dbQueries.GetChirp(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if chirp.RechirpOfID.Valid {
+		chirp, err = cfg.dbQueries.GetChirp(ctx, chirp.RechirpOfID.UUID)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	if chirp.DeletedAt.Valid {
+		return database.Chirp{}, errors.New("chirp has been deleted")
+	}
+	if !chirp.Published {
+		return database.Chirp{}, errors.New("chirp has not been published")
+	}
+	return chirp, nil
+}
+
+// withReferencedChirps embeds the chirps that a batch of rechirps and quote
+// chirps point at, and returns the embedded responses so they can be
+// enriched along with the rest of the batch. An original that has since
+// been deleted outright, has an edit held for moderation, or whose author
+// is hidden from viewerID by a block or mute, is left out, keeping only its
+// id.
+func (cfg *apiConfig) withReferencedChirps(ctx context.Context, viewerID uuid.NullUUID, chirps []*ChirpResponse) ([]*ChirpResponse, error) {
+	var referencedIDs []uuid.UUID
+	for _, chirp := range chirps {
+		if chirp.RechirpOfID != nil {
+			referencedIDs = append(referencedIDs, *chirp.RechirpOfID)
+		}
+		if chirp.QuoteOfID != nil {
+			referencedIDs = append(referencedIDs, *chirp.QuoteOfID)
+		}
+	}
+	if len(referencedIDs) == 0 {
+		return nil, nil
+	}
+
+	params := database.GetChirpsByIDsParams{
+		Ids:      referencedIDs,
+		ViewerID: viewerID,
+	}
+	referencedChirps, err := cfg.dbQueries.GetChirpsByIDs(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	referencedByID := make(map[uuid.UUID]database.Chirp, len(referencedChirps))
+	for _, referencedChirp := range referencedChirps {
+		referencedByID[referencedChirp.ID] = referencedChirp
+	}
+
+	var embedded []*ChirpResponse
+	embed := func(id *uuid.UUID) *ChirpResponse {
+		if id == nil {
+			return nil
+		}
+		referencedChirp, ok := referencedByID[*id]
+		if !ok {
+			return nil
+		}
+		chirpResponse := newChirpResponse(referencedChirp)
+		embedded = append(embedded, &chirpResponse)
+		return &chirpResponse
+	}
+	for _, chirp := range chirps {
+		chirp.RechirpOf = embed(chirp.RechirpOfID)
+		chirp.QuoteOf = embed(chirp.QuoteOfID)
+	}
+
+	return embedded, nil
+}
+
+// withShareCounts fills in rechirp_count and quote_count for a batch of
+// chirps with a single query.
+func (cfg *apiConfig) withShareCounts(ctx context.Context, chirps []*ChirpResponse) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, len(chirps))
+	for i, chirp := range chirps {
+		chirpIDs[i] = chirp.ID
+	}
+
+	counts, err := cfg.dbQueries.GetChirpShareCounts(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	countsByChirpID := make(map[uuid.UUID]database.GetChirpShareCountsRow, len(counts))
+	for _, count := range counts {
+		countsByChirpID[count.ChirpID] = count
+	}
+	for _, chirp := range chirps {
+		count := countsByChirpID[chirp.ID]
+		chirp.RechirpCount = count.RechirpCount
+		chirp.QuoteCount = count.QuoteCount
+	}
+
+	return nil
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/exy63/chirpy/internal/auth"
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxScheduleAhead = 365 * 24 * time.Hour
+	publishInterval  = 10 * time.Second
+	publishBatchSize = 100
+)
+
+// chirpVisibleTo hides scheduled chirps and chirps under moderation from
+// everyone but their author until they are published.
+func chirpVisibleTo(chirp database.Chirp, viewerID uuid.NullUUID) bool {
+	return chirp.Published || (viewerID.Valid && viewerID.UUID == chirp.UserID)
+}
+
+func validatePublishAt(publishAt time.Time) error {
+	now := time.Now()
+	if !publishAt.After(now) {
+		return errors.New("publish_at must be in the future")
+	}
+	if publishAt.After(now.Add(maxScheduleAhead)) {
+		return errors.New("publish_at must be within a year")
+	}
+	return nil
+}
+
+// runScheduledPublisher publishes due chirps until ctx is cancelled. Every
+// server instance runs one; PublishDueChirps claims rows with SKIP LOCKED,
+// so concurrent publishers never release the same chirp twice.
+func (cfg *apiConfig) runScheduledPublisher(ctx context.Context) {
+	ticker := time.NewTicker(publishInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			published, err := cfg.publishDueChirps(ctx)
+			if err != nil {
+				log.Printf("Couldn't publish scheduled chirps: %v", err)
+				break
+			}
+			if published < publishBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) (int, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirps, err := qtx.PublishDueChirps(ctx, publishBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, chirp := range chirps {
+		if err := syncChirpHashtags(ctx, qtx, chirp); err != nil {
+			return 0, err
+		}
+		if err := notifyChirpPublished(ctx, qtx, chirp); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	for _, chirp := range chirps {
+		cfg.broadcastChirpCreated(ctx, chirp)
+	}
+	return len(chirps), nil
+}
+
+func (cfg *apiConfig) handlerGetScheduledChirps(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	chirps, err := cfg.dbQueries.GetScheduledChirps(r.Context(), UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	chirpsResponse := make([]ChirpResponse, len(chirps))
+	chirpResponses := make([]*ChirpResponse, len(chirps))
+	for i, chirp := range chirps {
+		chirpsResponse[i] = newChirpResponse(chirp)
+		chirpResponses[i] = &chirpsResponse[i]
+	}
+	if err := cfg.enrichChirps(r.Context(), uuid.NullUUID{UUID: UserID, Valid: true}, chirpResponses); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpsResponse)
+}
+
+func (cfg *apiConfig) handlerGetScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id := r.PathValue("id")
+	chirpID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetScheduledChirpParams{
+		ID:     chirpID,
+		UserID: UserID,
+	}
+	chirp, err := cfg.dbQueries.GetScheduledChirp(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Scheduled chirp not found")
+		return
+	}
+
+	chirpResponse := newChirpResponse(chirp)
+	if err := cfg.enrichChirps(r.Context(), uuid.NullUUID{UUID: UserID, Valid: true}, []*ChirpResponse{&chirpResponse}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpResponse)
+}
+
+func (cfg *apiConfig) handlerUpdateScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id := r.PathValue("id")
+	chirpID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	type Request struct {
+		Body      *string    `json:"body"`
+		PublishAt *time.Time `json:"publish_at"`
+	}
+	var req Request
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body format")
+		return
+	}
+
+	getParams := database.GetScheduledChirpParams{
+		ID:     chirpID,
+		UserID: UserID,
+	}
+	chirp, err := cfg.dbQueries.GetScheduledChirp(r.Context(), getParams)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Scheduled chirp not found")
+		return
+	}
+
+	cleaned := cleanedBody{
+		Body:             chirp.Body,
+		OriginalBody:     chirp.OriginalBody,
+		ModerationStatus: chirp.ModerationStatus,
+	}
+	if req.Body != nil {
+		policy, err := cfg.chirpPolicy(r.Context(), UserID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		cleaned, err = getCleanedBody(*req.Body, policy)
+		if err != nil {
+			respondWithBodyError(w, err)
+			return
+		}
+	}
+
+	publishAt := chirp.PublishAt
+	if req.PublishAt != nil {
+		if err := validatePublishAt(*req.PublishAt); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		publishAt = sql.NullTime{Time: req.PublishAt.UTC(), Valid: true}
+	}
+
+	updateParams := database.UpdateScheduledChirpParams{
+		Body:             cleaned.Body,
+		OriginalBody:     cleaned.OriginalBody,
+		ModerationStatus: cleaned.ModerationStatus,
+		PublishAt:        publishAt,
+		ID:               chirp.ID,
+		UserID:           UserID,
+	}
+	updatedChirp, err := cfg.dbQueries.UpdateScheduledChirp(r.Context(), updateParams)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Chirp has already been published")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	chirpResponse := newChirpResponse(updatedChirp)
+	if err := cfg.enrichChirps(r.Context(), uuid.NullUUID{UUID: UserID, Valid: true}, []*ChirpResponse{&chirpResponse}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpResponse)
+}
+
+func (cfg *apiConfig) handlerDeleteScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	id := r.PathValue("id")
+	chirpID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	attachments, err := cfg.dbQueries.GetMediaForChirps(r.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	params := database.DeleteScheduledChirpParams{
+		ID:     chirpID,
+		UserID: UserID,
+	}
+	deleted, err := cfg.dbQueries.DeleteScheduledChirp(r.Context(), params)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	for _, attachment := range attachments {
+		cfg.deleteStoredMedia(r.Context(), attachment.StorageKey, attachment.ThumbnailKey)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/exy63/chirpy/internal/database"
+)
+
+type ChirpSearchResult struct {
+	ChirpResponse
+	Rank            float32 `json:"rank"`
+	HighlightedBody string  `json:"highlighted_body"`
+}
+
+type ChirpSearchResponse struct {
+	Results    []ChirpSearchResult `json:"results"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+	PrevCursor string              `json:"prev_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	query := r.URL.Query()
+
+	searchQuery := strings.TrimSpace(query.Get("q"))
+	if searchQuery == "" {
+		respondWithError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	authorIDs, err := parseAuthorIDs(query["author_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var offset int32
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		offset, err = decodeOffsetCursor(rawCursor)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	viewerID := cfg.viewerID(r)
+	params := database.SearchChirpsParams{
+		Query:    searchQuery,
+		UserIds:  authorIDs,
+		Limit:    limit + 1,
+		Offset:   offset,
+		ViewerID: viewerID,
+	}
+	rows, err := cfg.dbQueries.SearchChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	hasMore := len(rows) > int(limit)
+	if hasMore {
+		rows = rows[:limit]
+	}
+
+	res := ChirpSearchResponse{Results: make([]ChirpSearchResult, len(rows))}
+	chirpResponses := make([]*ChirpResponse, len(rows))
+	for i, row := range rows {
+		res.Results[i] = ChirpSearchResult{
+			ChirpResponse:   newChirpResponse(row.Chirp),
+			Rank:            row.Rank,
+			HighlightedBody: row.HighlightedBody,
+		}
+		chirpResponses[i] = &res.Results[i].ChirpResponse
+	}
+	if err := cfg.enrichChirps(r.Context(), viewerID, chirpResponses); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if hasMore {
+		res.NextCursor = encodeOffsetCursor(offset + limit)
+	}
+	if offset > 0 {
+		res.PrevCursor = encodeOffsetCursor(max(offset-limit, 0))
+	}
+
+	respondWithJSON(w, http.StatusOK, res)
+}
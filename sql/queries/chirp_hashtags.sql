@@ -0,0 +1,35 @@
+-- name: AddChirpHashtags :exec
+INSERT INTO chirp_hashtags (chirp_id, tag, created_at)
+SELECT sqlc.arg('chirp_id'), unnest(sqlc.arg('tags')::text[]), sqlc.arg('created_at')
+ON CONFLICT (chirp_id, tag) DO NOTHING;
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: GetHashtagChirps :many
+SELECT sqlc.embed(chirps)
+FROM chirp_hashtags
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.tag = sqlc.arg('tag')
+	AND chirps.deleted_at IS NULL
+	AND NOT author_hidden_from(chirps.user_id, sqlc.narg('viewer_id')::uuid)
+	AND (sqlc.narg('before_created_at')::timestamp IS NULL
+		OR (chirp_hashtags.created_at, chirp_hashtags.chirp_id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_chirp_id')::uuid))
+ORDER BY chirp_hashtags.created_at DESC, chirp_hashtags.chirp_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTrendingHashtags :many
+SELECT tag,
+	COUNT(*) FILTER (WHERE created_at >= NOW() - sqlc.arg('window_seconds')::integer * INTERVAL '1 second') AS recent_count,
+	COUNT(*) FILTER (WHERE created_at < NOW() - sqlc.arg('window_seconds')::integer * INTERVAL '1 second') AS previous_count
+FROM chirp_hashtags
+WHERE created_at >= NOW() - 2 * sqlc.arg('window_seconds')::integer * INTERVAL '1 second'
+GROUP BY tag
+HAVING COUNT(*) FILTER (WHERE created_at >= NOW() - sqlc.arg('window_seconds')::integer * INTERVAL '1 second') > 0
+ORDER BY
+	COUNT(*) FILTER (WHERE created_at >= NOW() - sqlc.arg('window_seconds')::integer * INTERVAL '1 second')
+		- COUNT(*) FILTER (WHERE created_at < NOW() - sqlc.arg('window_seconds')::integer * INTERVAL '1 second') DESC,
+	recent_count DESC,
+	tag ASC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
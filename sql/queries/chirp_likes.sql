@@ -0,0 +1,32 @@
+-- name: LikeChirp :execrows
+INSERT INTO chirp_likes (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+	$2,
+	NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnlikeChirp :exec
+DELETE FROM chirp_likes
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetChirpLikeStats :many
+SELECT chirp_id,
+	COUNT(*) AS like_count,
+	COALESCE(BOOL_OR(user_id = sqlc.narg('viewer_id')::uuid), false)::boolean AS liked_by_me
+FROM chirp_likes
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY chirp_id;
+
+-- name: GetUserLikedChirps :many
+SELECT sqlc.embed(chirps), chirp_likes.created_at AS liked_at
+FROM chirp_likes
+JOIN chirps ON chirps.id = chirp_likes.chirp_id
+WHERE chirp_likes.user_id = sqlc.arg('user_id')
+	AND chirps.deleted_at IS NULL
+	AND chirps.published
+	AND (sqlc.narg('before_liked_at')::timestamp IS NULL
+		OR (chirp_likes.created_at, chirp_likes.chirp_id) < (sqlc.narg('before_liked_at')::timestamp, sqlc.narg('before_chirp_id')::uuid))
+ORDER BY chirp_likes.created_at DESC, chirp_likes.chirp_id DESC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
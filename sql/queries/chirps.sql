@@ -1,23 +1,257 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (
-    gen_random_uuid(),
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status)
+SELECT
+	new_chirp.id,
 	NOW(),
 	NOW(),
-	$1,
-	$2
-)
+	sqlc.arg('body'),
+	sqlc.arg('user_id'),
+	sqlc.narg('parent_id')::uuid,
+	COALESCE(
+		(SELECT parent.root_id FROM chirps AS parent WHERE parent.id = sqlc.narg('parent_id')::uuid),
+		new_chirp.id
+	),
+	sqlc.narg('rechirp_of_id')::uuid,
+	sqlc.narg('quote_of_id')::uuid,
+	sqlc.arg('published'),
+	sqlc.narg('publish_at')::timestamp,
+	sqlc.narg('original_body')::text,
+	sqlc.arg('moderation_status')
+FROM (SELECT gen_random_uuid() AS id) AS new_chirp
 RETURNING *;
 
--- name: GetChirps :many
+-- name: GetChirpsAsc :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+	AND published
+	AND NOT author_hidden_from(chirps.user_id, sqlc.narg('viewer_id')::uuid)
+	AND (COALESCE(cardinality(sqlc.arg('user_ids')::uuid[]), 0) = 0 OR user_id = ANY(sqlc.arg('user_ids')::uuid[]))
+	AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+	AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+	AND (sqlc.narg('contains')::text IS NULL OR body ILIKE '%' || sqlc.narg('contains')::text || '%')
+	AND (sqlc.narg('after_created_at')::timestamp IS NULL
+		OR (created_at, id) > (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsDesc :many
 SELECT * FROM chirps
-WHERE user_id = COALESCE(sqlc.narg('user_id'), user_id)
-ORDER BY created_at COALESCE(sqlc.narg('sort'), ASC);
+WHERE deleted_at IS NULL
+	AND published
+	AND NOT author_hidden_from(chirps.user_id, sqlc.narg('viewer_id')::uuid)
+	AND (COALESCE(cardinality(sqlc.arg('user_ids')::uuid[]), 0) = 0 OR user_id = ANY(sqlc.arg('user_ids')::uuid[]))
+	AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+	AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+	AND (sqlc.narg('contains')::text IS NULL OR body ILIKE '%' || sqlc.narg('contains')::text || '%')
+	AND (sqlc.narg('before_created_at')::timestamp IS NULL
+		OR (created_at, id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsByLikes :many
+SELECT chirps.* FROM chirps
+LEFT JOIN (
+	SELECT chirp_id, COUNT(*) AS like_count FROM chirp_likes
+	GROUP BY chirp_id
+) AS likes ON likes.chirp_id = chirps.id
+WHERE chirps.deleted_at IS NULL
+	AND chirps.published
+	AND NOT author_hidden_from(chirps.user_id, sqlc.narg('viewer_id')::uuid)
+	AND (COALESCE(cardinality(sqlc.arg('user_ids')::uuid[]), 0) = 0 OR chirps.user_id = ANY(sqlc.arg('user_ids')::uuid[]))
+	AND (sqlc.narg('since')::timestamp IS NULL OR chirps.created_at >= sqlc.narg('since')::timestamp)
+	AND (sqlc.narg('until')::timestamp IS NULL OR chirps.created_at < sqlc.narg('until')::timestamp)
+	AND (sqlc.narg('contains')::text IS NULL OR chirps.body ILIKE '%' || sqlc.narg('contains')::text || '%')
+ORDER BY
+	CASE WHEN sqlc.arg('descending')::boolean THEN COALESCE(likes.like_count, 0) END DESC,
+	CASE WHEN NOT sqlc.arg('descending')::boolean THEN COALESCE(likes.like_count, 0) END ASC,
+	chirps.created_at DESC,
+	chirps.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: GetChirp :one
 SELECT * FROM chirps
 WHERE id = $1;
 
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+	AND published
+	AND NOT author_hidden_from(chirps.user_id, sqlc.narg('viewer_id')::uuid);
+
+-- name: GetChirpShareCounts :many
+SELECT shared.chirp_id::uuid AS chirp_id,
+	COUNT(*) FILTER (WHERE shared.kind = 'rechirp') AS rechirp_count,
+	COUNT(*) FILTER (WHERE shared.kind = 'quote') AS quote_count
+FROM (
+	SELECT rechirp_of_id AS chirp_id, 'rechirp' AS kind FROM chirps
+	WHERE rechirp_of_id = ANY(sqlc.arg('chirp_ids')::uuid[]) AND deleted_at IS NULL AND published
+	UNION ALL
+	SELECT quote_of_id AS chirp_id, 'quote' AS kind FROM chirps
+	WHERE quote_of_id = ANY(sqlc.arg('chirp_ids')::uuid[]) AND deleted_at IS NULL AND published
+) AS shared
+GROUP BY shared.chirp_id;
+
+-- name: GetChirpForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = sqlc.arg('body'),
+	original_body = sqlc.narg('original_body'),
+	moderation_status = sqlc.arg('moderation_status'),
+	published = published AND sqlc.arg('moderation_status') = 'approved',
+	edited_at = NOW(),
+	updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
 -- name: DeleteChirp :exec
 DELETE FROM chirps
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: CountChirpReplies :one
+SELECT COUNT(*) FROM chirps
+WHERE parent_id = $1;
+
+-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '',
+	deleted_at = NOW(),
+	updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetChirpThread :many
+WITH RECURSIVE thread AS (
+	SELECT chirps.id,
+		0 AS depth,
+		ARRAY[to_char(chirps.created_at, 'YYYYMMDDHH24MISSUS') || chirps.id::text] AS path
+	FROM chirps
+	WHERE chirps.id = sqlc.arg('root_id')::uuid
+	UNION ALL
+	SELECT replies.id,
+		thread.depth + 1,
+		thread.path || (to_char(replies.created_at, 'YYYYMMDDHH24MISSUS') || replies.id::text)
+	FROM chirps AS replies
+	JOIN thread ON replies.parent_id = thread.id
+	WHERE replies.published
+)
+SELECT sqlc.embed(chirps),
+	thread.depth::integer AS depth,
+	author_hidden_from(chirps.user_id, sqlc.narg('viewer_id')::uuid) AS hidden
+FROM thread
+JOIN chirps ON chirps.id = thread.id
+ORDER BY thread.path;
+
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps),
+	ts_rank(chirps.body_tsv, search_query)::real AS rank,
+	ts_headline(
+		'english',
+		replace(replace(replace(chirps.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+		search_query,
+		'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'
+	) AS highlighted_body
+FROM chirps, websearch_to_tsquery('english', sqlc.arg('query')::text) AS search_query
+WHERE chirps.body_tsv @@ search_query
+	AND chirps.deleted_at IS NULL
+	AND chirps.published
+	AND NOT author_hidden_from(chirps.user_id, sqlc.narg('viewer_id')::uuid)
+	AND (COALESCE(cardinality(sqlc.arg('user_ids')::uuid[]), 0) = 0 OR chirps.user_id = ANY(sqlc.arg('user_ids')::uuid[]))
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: GetScheduledChirps :many
+SELECT * FROM chirps
+WHERE user_id = $1 AND published = false AND publish_at IS NOT NULL
+ORDER BY publish_at ASC, id ASC;
+
+-- name: GetScheduledChirp :one
+SELECT * FROM chirps
+WHERE id = $1 AND user_id = $2 AND published = false AND publish_at IS NOT NULL;
+
+-- name: UpdateScheduledChirp :one
+UPDATE chirps
+SET body = sqlc.arg('body'),
+	original_body = sqlc.narg('original_body'),
+	moderation_status = sqlc.arg('moderation_status'),
+	publish_at = sqlc.arg('publish_at'),
+	updated_at = NOW()
+WHERE id = sqlc.arg('id') AND user_id = sqlc.arg('user_id') AND published = false AND publish_at IS NOT NULL
+RETURNING *;
+
+-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1 AND user_id = $2 AND published = false AND publish_at IS NOT NULL;
+
+-- name: PublishDueChirps :many
+UPDATE chirps
+SET published = true,
+	created_at = NOW(),
+	updated_at = NOW()
+WHERE id IN (
+	SELECT due.id FROM chirps AS due
+	WHERE due.published = false AND due.publish_at <= NOW() AND due.moderation_status = 'approved'
+	ORDER BY due.publish_at
+	LIMIT sqlc.arg('limit')
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: GetHeldChirps :many
+SELECT * FROM chirps
+WHERE moderation_status = 'held'
+	AND deleted_at IS NULL
+	AND (sqlc.narg('after_created_at')::timestamp IS NULL
+		OR (created_at, id) > (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: ApproveHeldChirp :one
+UPDATE chirps
+SET moderation_status = 'approved',
+	published = publish_at IS NULL OR publish_at <= NOW(),
+	created_at = CASE WHEN publish_at <= NOW() THEN NOW() ELSE created_at END,
+	updated_at = NOW()
+WHERE id = $1 AND moderation_status = 'held'
+RETURNING *;
+
+-- name: RejectHeldChirp :one
+UPDATE chirps
+SET moderation_status = 'rejected',
+	updated_at = NOW()
+WHERE id = $1 AND moderation_status = 'held'
+RETURNING *;
+
+-- name: GetUserChirpsForExport :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+	AND deleted_at IS NULL
+	AND (sqlc.narg('after_created_at')::timestamp IS NULL
+		OR (created_at, id) > (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: ImportChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, rechirp_of_id, quote_of_id, published, publish_at, original_body, moderation_status, edited_at)
+SELECT
+	new_chirp.id,
+	sqlc.arg('created_at'),
+	sqlc.arg('updated_at'),
+	sqlc.arg('body'),
+	sqlc.arg('user_id'),
+	sqlc.narg('parent_id')::uuid,
+	COALESCE(
+		(SELECT parent.root_id FROM chirps AS parent WHERE parent.id = sqlc.narg('parent_id')::uuid),
+		new_chirp.id
+	),
+	sqlc.narg('rechirp_of_id')::uuid,
+	sqlc.narg('quote_of_id')::uuid,
+	sqlc.arg('published'),
+	sqlc.narg('publish_at')::timestamp,
+	sqlc.narg('original_body')::text,
+	sqlc.arg('moderation_status'),
+	sqlc.narg('edited_at')::timestamp
+FROM (SELECT gen_random_uuid() AS id) AS new_chirp
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,55 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, last_activity_at)
+VALUES (gen_random_uuid(), NOW(), NOW(), NOW())
+RETURNING *;
+
+-- name: AddConversationMembers :exec
+INSERT INTO conversation_members (conversation_id, user_id, joined_at)
+SELECT sqlc.arg('conversation_id'), unnest(sqlc.arg('user_ids')::uuid[]), NOW();
+
+-- name: GetMemberConversation :one
+SELECT conversations.* FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversations.id = $1 AND conversation_members.user_id = $2;
+
+-- name: GetUserConversations :many
+SELECT sqlc.embed(conversations),
+	(
+		SELECT COUNT(*) FROM direct_messages
+		WHERE direct_messages.conversation_id = conversations.id
+			AND direct_messages.sender_id <> conversation_members.user_id
+			AND direct_messages.deleted_at IS NULL
+			AND (conversation_members.last_read_at IS NULL OR direct_messages.created_at > conversation_members.last_read_at)
+	) AS unread_count
+FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversation_members.user_id = sqlc.arg('user_id')
+	AND (sqlc.narg('before_activity_at')::timestamp IS NULL
+		OR (conversations.last_activity_at, conversations.id) < (sqlc.narg('before_activity_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY conversations.last_activity_at DESC, conversations.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetConversationMembers :many
+SELECT * FROM conversation_members
+WHERE conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+ORDER BY joined_at ASC, user_id ASC;
+
+-- name: CountUnreadMessages :one
+SELECT COUNT(*) FROM direct_messages
+JOIN conversation_members ON conversation_members.conversation_id = direct_messages.conversation_id
+WHERE conversation_members.user_id = sqlc.arg('user_id')
+	AND (sqlc.narg('conversation_id')::uuid IS NULL OR direct_messages.conversation_id = sqlc.narg('conversation_id')::uuid)
+	AND direct_messages.sender_id <> conversation_members.user_id
+	AND direct_messages.deleted_at IS NULL
+	AND (conversation_members.last_read_at IS NULL OR direct_messages.created_at > conversation_members.last_read_at);
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_members
+SET last_read_at = GREATEST(last_read_at, sqlc.arg('read_at')::timestamp)
+WHERE conversation_id = sqlc.arg('conversation_id') AND user_id = sqlc.arg('user_id');
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET last_activity_at = $2,
+	updated_at = NOW()
+WHERE id = $1;
\ No newline at end of file
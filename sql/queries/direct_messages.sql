@@ -0,0 +1,32 @@
+-- name: CreateDirectMessage :one
+INSERT INTO direct_messages (id, conversation_id, sender_id, created_at, updated_at, body)
+VALUES (gen_random_uuid(), $1, $2, NOW(), NOW(), $3)
+RETURNING *;
+
+-- name: GetDirectMessages :many
+SELECT * FROM direct_messages
+WHERE conversation_id = sqlc.arg('conversation_id')
+	AND (sqlc.narg('before_created_at')::timestamp IS NULL
+		OR (created_at, id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetDirectMessageForUpdate :one
+SELECT * FROM direct_messages
+WHERE id = $1 AND conversation_id = $2
+FOR UPDATE;
+
+-- name: UpdateDirectMessage :one
+UPDATE direct_messages
+SET body = $2,
+	edited_at = NOW(),
+	updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteDirectMessage :exec
+UPDATE direct_messages
+SET body = '',
+	deleted_at = NOW(),
+	updated_at = NOW()
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,24 @@
+-- name: CreateEmailVerificationToken :one
+INSERT INTO email_verification_tokens (token_hash, user_id, email, created_at, expires_at)
+VALUES (
+    $1,
+	$2,
+	$3,
+	NOW(),
+	$4
+)
+RETURNING *;
+
+-- name: GetEmailVerificationToken :one
+SELECT * FROM email_verification_tokens
+WHERE token_hash = $1 AND expires_at > NOW();
+
+-- name: GetPendingEmailVerification :one
+SELECT * FROM email_verification_tokens
+WHERE user_id = $1 AND expires_at > NOW()
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: DeleteEmailVerificationTokens :exec
+DELETE FROM email_verification_tokens
+WHERE user_id = $1;
\ No newline at end of file
@@ -0,0 +1,39 @@
+-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES (
+    $1,
+	$2,
+	NOW()
+)
+ON CONFLICT (follower_id, followee_id) DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT follower_id AS user_id, created_at AS followed_at
+FROM follows
+WHERE followee_id = sqlc.arg('user_id')
+	AND (sqlc.narg('before_followed_at')::timestamp IS NULL
+		OR (created_at, follower_id) < (sqlc.narg('before_followed_at')::timestamp, sqlc.narg('before_user_id')::uuid))
+ORDER BY created_at DESC, follower_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetFollowing :many
+SELECT followee_id AS user_id, created_at AS followed_at
+FROM follows
+WHERE follower_id = sqlc.arg('user_id')
+	AND (sqlc.narg('before_followed_at')::timestamp IS NULL
+		OR (created_at, followee_id) < (sqlc.narg('before_followed_at')::timestamp, sqlc.narg('before_user_id')::uuid))
+ORDER BY created_at DESC, followee_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetFollowStats :one
+SELECT
+	(SELECT COUNT(*) FROM follows WHERE followee_id = sqlc.arg('user_id')) AS follower_count,
+	(SELECT COUNT(*) FROM follows WHERE follower_id = sqlc.arg('user_id')) AS following_count,
+	EXISTS (
+		SELECT 1 FROM follows
+		WHERE follower_id = sqlc.narg('viewer_id')::uuid AND followee_id = sqlc.arg('user_id')
+	)::boolean AS followed_by_me;
\ No newline at end of file
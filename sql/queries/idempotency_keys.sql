@@ -0,0 +1,31 @@
+-- name: ClaimIdempotencyKey :one
+INSERT INTO idempotency_keys (scope, key, created_at, expires_at, request_hash)
+VALUES ($1, $2, NOW(), $3, $4)
+ON CONFLICT (scope, key) DO UPDATE
+SET created_at = NOW(),
+	expires_at = EXCLUDED.expires_at,
+	request_hash = EXCLUDED.request_hash,
+	status_code = NULL,
+	content_type = '',
+	response_body = ''
+WHERE idempotency_keys.expires_at <= NOW()
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE scope = $1 AND key = $2;
+
+-- name: SaveIdempotentResponse :exec
+UPDATE idempotency_keys
+SET status_code = $3,
+	content_type = $4,
+	response_body = $5
+WHERE scope = $1 AND key = $2;
+
+-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE scope = $1 AND key = $2;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at <= NOW();
\ No newline at end of file
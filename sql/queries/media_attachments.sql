@@ -0,0 +1,49 @@
+-- name: CreateMediaAttachment :one
+INSERT INTO media_attachments (
+	id,
+	created_at,
+	user_id,
+	content_type,
+	storage_key,
+	width,
+	height,
+	thumbnail_key,
+	thumbnail_width,
+	thumbnail_height,
+	size_bytes
+)
+VALUES (
+    gen_random_uuid(),
+	NOW(),
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7,
+	$8,
+	$9
+)
+RETURNING *;
+
+-- name: AttachMediaToChirp :one
+UPDATE media_attachments
+SET chirp_id = sqlc.arg('chirp_id'),
+	alt_text = sqlc.arg('alt_text'),
+	position = sqlc.arg('position')
+WHERE id = sqlc.arg('id') AND user_id = sqlc.arg('user_id') AND chirp_id IS NULL
+RETURNING *;
+
+-- name: GetMediaForChirps :many
+SELECT * FROM media_attachments
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: DeleteChirpMedia :exec
+DELETE FROM media_attachments
+WHERE chirp_id = $1;
+
+-- name: GetMediaAttachment :one
+SELECT * FROM media_attachments
+WHERE id = $1;
\ No newline at end of file
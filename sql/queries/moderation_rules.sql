@@ -0,0 +1,20 @@
+-- name: CreateModerationRule :one
+INSERT INTO moderation_rules (term, action)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetModerationRules :many
+SELECT * FROM moderation_rules
+ORDER BY term ASC;
+
+-- name: UpdateModerationRule :one
+UPDATE moderation_rules
+SET term = $2,
+	action = $3,
+	updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteModerationRule :execrows
+DELETE FROM moderation_rules
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,63 @@
+-- name: CreateNotification :exec
+INSERT INTO notifications (id, user_id, type, actor_id, chirp_id, created_at)
+SELECT
+	gen_random_uuid(),
+	sqlc.arg('user_id')::uuid,
+	sqlc.arg('type')::text,
+	sqlc.narg('actor_id')::uuid,
+	sqlc.narg('chirp_id')::uuid,
+	NOW()
+WHERE sqlc.arg('user_id')::uuid IS DISTINCT FROM sqlc.narg('actor_id')::uuid
+	AND NOT author_hidden_from(sqlc.narg('actor_id')::uuid, sqlc.arg('user_id')::uuid)
+	AND NOT EXISTS (
+		SELECT 1 FROM notification_preferences
+		WHERE user_id = sqlc.arg('user_id')::uuid AND type = sqlc.arg('type')::text AND NOT enabled
+	);
+
+-- name: GetNotifications :many
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg('user_id')
+	AND (NOT sqlc.arg('unread_only')::boolean OR read_at IS NULL)
+	AND (sqlc.narg('before_created_at')::timestamp IS NULL
+		OR (created_at, id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET read_at = COALESCE(read_at, NOW())
+WHERE id = $1 AND user_id = $2;
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: GetNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1
+ORDER BY type;
+
+-- name: SetNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, type, enabled, updated_at)
+VALUES (
+    $1,
+	$2,
+	$3,
+	NOW()
+)
+ON CONFLICT (user_id, type) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+	updated_at = EXCLUDED.updated_at;
+
+-- name: DeleteNotificationPreference :exec
+DELETE FROM notification_preferences
+WHERE user_id = $1 AND type = $2;
+
+-- name: DeleteNotificationPreferences :exec
+DELETE FROM notification_preferences
+WHERE user_id = $1;
\ No newline at end of file
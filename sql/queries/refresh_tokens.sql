@@ -18,4 +18,9 @@ WHERE token = $1 AND expires_at > NOW() AND revoked_at IS NULL;
 UPDATE refresh_tokens
 SET revoked_at = NOW(),
 	updated_at = NOW()
-WHERE token = $1;
\ No newline at end of file
+WHERE token = $1;
+
+-- name: GetUserRefreshTokens :many
+SELECT * FROM refresh_tokens
+WHERE user_id = $1
+ORDER BY created_at ASC;
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- name: GetTimeline :many
+SELECT timeline.* FROM (
+	SELECT sqlc.arg('user_id')::uuid AS author_id
+	UNION ALL
+	SELECT followee_id FROM follows
+	WHERE follower_id = sqlc.arg('user_id')::uuid
+		AND NOT author_hidden_from(followee_id, sqlc.arg('user_id')::uuid)
+) AS authors
+CROSS JOIN LATERAL (
+	SELECT * FROM chirps
+	WHERE chirps.user_id = authors.author_id
+		AND deleted_at IS NULL
+		AND published
+		AND (sqlc.narg('before_created_at')::timestamp IS NULL
+			OR (created_at, id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+	ORDER BY created_at DESC, id DESC
+	LIMIT sqlc.arg('limit')
+) AS timeline
+ORDER BY timeline.created_at DESC, timeline.id DESC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
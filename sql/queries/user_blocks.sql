@@ -0,0 +1,36 @@
+-- name: BlockUser :execrows
+INSERT INTO user_blocks (blocker_id, blocked_id, created_at)
+VALUES (
+    $1,
+	$2,
+	NOW()
+)
+ON CONFLICT (blocker_id, blocked_id) DO NOTHING;
+
+-- name: UnblockUser :execrows
+DELETE FROM user_blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: DeleteFollowsBetween :exec
+DELETE FROM follows
+WHERE (follower_id = sqlc.arg('user_id') AND followee_id = sqlc.arg('other_user_id'))
+	OR (follower_id = sqlc.arg('other_user_id') AND followee_id = sqlc.arg('user_id'));
+
+-- name: IsBlockedBetween :one
+SELECT EXISTS (
+	SELECT 1 FROM user_blocks
+	WHERE (blocker_id = sqlc.arg('user_id') AND blocked_id = ANY(sqlc.arg('other_user_ids')::uuid[]))
+		OR (blocked_id = sqlc.arg('user_id') AND blocker_id = ANY(sqlc.arg('other_user_ids')::uuid[]))
+)::boolean AS blocked;
+
+-- name: IsAuthorHidden :one
+SELECT author_hidden_from(sqlc.arg('author_id')::uuid, sqlc.narg('viewer_id')::uuid)::boolean AS hidden;
+
+-- name: GetBlockedUsers :many
+SELECT blocked_id AS user_id, created_at
+FROM user_blocks
+WHERE blocker_id = sqlc.arg('user_id')
+	AND (sqlc.narg('before_created_at')::timestamp IS NULL
+		OR (created_at, blocked_id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_user_id')::uuid))
+ORDER BY created_at DESC, blocked_id DESC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
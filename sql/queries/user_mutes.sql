@@ -0,0 +1,21 @@
+-- name: MuteUser :execrows
+INSERT INTO user_mutes (muter_id, muted_id, created_at)
+VALUES (
+    $1,
+	$2,
+	NOW()
+)
+ON CONFLICT (muter_id, muted_id) DO NOTHING;
+
+-- name: UnmuteUser :execrows
+DELETE FROM user_mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: GetMutedUsers :many
+SELECT muted_id AS user_id, created_at
+FROM user_mutes
+WHERE muter_id = sqlc.arg('user_id')
+	AND (sqlc.narg('before_created_at')::timestamp IS NULL
+		OR (created_at, muted_id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_user_id')::uuid))
+ORDER BY created_at DESC, muted_id DESC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
@@ -1,11 +1,12 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid(),
 	NOW(),
 	NOW(),
-	$1,
-	$2
+	sqlc.arg('email'),
+	sqlc.arg('hashed_password'),
+	COALESCE(sqlc.narg('handle')::text, 'user_' || substr(md5(gen_random_uuid()::text), 1, 10))
 )
 RETURNING *;
 
@@ -22,13 +23,47 @@ WHERE id = $1;
 
 -- name: UpdateUser :one
 UPDATE users
-SET email = $2,
-	hashed_password = $3,
+SET email = sqlc.arg('email'),
+	hashed_password = sqlc.arg('hashed_password'),
+	handle = sqlc.arg('handle'),
+	display_name = sqlc.arg('display_name'),
+	bio = sqlc.arg('bio'),
+	website = sqlc.arg('website'),
+	avatar_media_id = sqlc.narg('avatar_media_id'),
 	updated_at = NOW()
-WHERE id = $1
+WHERE id = sqlc.arg('id')
 RETURNING *;
 
 -- name: UpgradeUser :exec
 UPDATE users
-SET is_chirpy_red = true
-WHERE id = $1;
\ No newline at end of file
+SET is_chirpy_red = true,
+	chirpy_red_expires_at = $2
+WHERE id = $1;
+
+-- name: GetUserByHandle :one
+SELECT * FROM users
+WHERE lower(handle) = lower(sqlc.arg('handle'));
+
+-- name: GetUsersByHandles :many
+SELECT * FROM users
+WHERE lower(handle) = ANY(sqlc.arg('handles')::text[]);
+
+-- name: GetUserSummaries :many
+SELECT users.id, users.handle, users.display_name, media_attachments.storage_key AS avatar_key
+FROM users
+LEFT JOIN media_attachments ON media_attachments.id = users.avatar_media_id
+WHERE users.id = ANY(sqlc.arg('user_ids')::uuid[]);
+
+-- name: GetUserStats :one
+SELECT
+	(SELECT COUNT(*) FROM chirps WHERE chirps.user_id = sqlc.arg('user_id') AND published AND deleted_at IS NULL) AS chirp_count,
+	(SELECT COUNT(*) FROM follows WHERE followee_id = sqlc.arg('user_id')) AS follower_count,
+	(SELECT COUNT(*) FROM follows WHERE follower_id = sqlc.arg('user_id')) AS following_count;
+
+-- name: VerifyUserEmail :one
+UPDATE users
+SET email = $2,
+	email_verified_at = NOW(),
+	updated_at = NOW()
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
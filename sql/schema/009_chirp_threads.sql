@@ -0,0 +1,31 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN parent_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+ADD COLUMN root_id UUID,
+ADD COLUMN deleted_at TIMESTAMP;
+
+UPDATE chirps SET root_id = id;
+
+ALTER TABLE chirps
+ALTER COLUMN root_id SET NOT NULL;
+
+CREATE INDEX chirps_parent_id_idx ON chirps (parent_id);
+CREATE INDEX chirps_root_id_idx ON chirps (root_id);
+
+-- Tombstones all carry an empty body, so uniqueness only applies to live chirps.
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_body_key;
+CREATE UNIQUE INDEX chirps_body_live_key ON chirps (body) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DELETE FROM chirps WHERE deleted_at IS NOT NULL;
+DROP INDEX chirps_body_live_key;
+ALTER TABLE chirps
+ADD CONSTRAINT chirps_body_key UNIQUE (body);
+
+DROP INDEX chirps_root_id_idx;
+DROP INDEX chirps_parent_id_idx;
+ALTER TABLE chirps
+DROP COLUMN deleted_at,
+DROP COLUMN root_id,
+DROP COLUMN parent_id;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE chirp_likes (
+	user_id UUID NOT NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	chirp_id UUID NOT NULL,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (user_id, chirp_id)
+);
+CREATE INDEX chirp_likes_chirp_id_idx ON chirp_likes (chirp_id);
+CREATE INDEX chirp_likes_user_id_created_at_idx ON chirp_likes (user_id, created_at, chirp_id);
+
+-- +goose Down
+DROP TABLE chirp_likes;
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- +goose Up
+-- The referenced chirp may later be deleted outright, so these ids carry no
+-- foreign key; a dangling id is how a response knows the original is gone.
+ALTER TABLE chirps
+ADD COLUMN rechirp_of_id UUID,
+ADD COLUMN quote_of_id UUID;
+
+CREATE INDEX chirps_rechirp_of_id_idx ON chirps (rechirp_of_id);
+CREATE INDEX chirps_quote_of_id_idx ON chirps (quote_of_id);
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_id_key ON chirps (user_id, rechirp_of_id)
+WHERE rechirp_of_id IS NOT NULL AND deleted_at IS NULL;
+
+-- Rechirps have no body of their own.
+DROP INDEX chirps_body_live_key;
+CREATE UNIQUE INDEX chirps_body_live_key ON chirps (body)
+WHERE deleted_at IS NULL AND rechirp_of_id IS NULL;
+
+-- +goose Down
+DELETE FROM chirps WHERE rechirp_of_id IS NOT NULL;
+DROP INDEX chirps_body_live_key;
+CREATE UNIQUE INDEX chirps_body_live_key ON chirps (body) WHERE deleted_at IS NULL;
+
+DROP INDEX chirps_user_id_rechirp_of_id_key;
+DROP INDEX chirps_quote_of_id_idx;
+DROP INDEX chirps_rechirp_of_id_idx;
+ALTER TABLE chirps
+DROP COLUMN quote_of_id,
+DROP COLUMN rechirp_of_id;
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE chirp_hashtags (
+	chirp_id UUID NOT NULL,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+	tag TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (chirp_id, tag)
+);
+CREATE INDEX chirp_hashtags_tag_created_at_idx ON chirp_hashtags (tag, created_at, chirp_id);
+CREATE INDEX chirp_hashtags_created_at_idx ON chirp_hashtags (created_at);
+
+-- Existing chirps get the same tags the server extracts for new ones.
+INSERT INTO chirp_hashtags (chirp_id, tag, created_at)
+SELECT DISTINCT chirps.id, lower(hashtag[1]), chirps.created_at
+FROM chirps,
+	regexp_matches(chirps.body, '(?:^|[^[:alnum:]_])#([[:alnum:]_]*[[:alpha:]][[:alnum:]_]*)', 'g') AS hashtag
+WHERE chirps.deleted_at IS NULL;
+
+-- +goose Down
+DROP TABLE chirp_hashtags;
\ No newline at end of file
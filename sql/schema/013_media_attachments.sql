@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE media_attachments (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	user_id UUID NOT NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	chirp_id UUID,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL DEFAULT 0,
+	alt_text TEXT NOT NULL DEFAULT '',
+	content_type TEXT NOT NULL,
+	storage_key TEXT NOT NULL UNIQUE,
+	width INTEGER NOT NULL,
+	height INTEGER NOT NULL,
+	thumbnail_key TEXT NOT NULL UNIQUE,
+	thumbnail_width INTEGER NOT NULL,
+	thumbnail_height INTEGER NOT NULL,
+	size_bytes INTEGER NOT NULL
+);
+CREATE INDEX media_attachments_chirp_id_position_idx ON media_attachments (chirp_id, position);
+
+-- +goose Down
+DROP TABLE media_attachments;
\ No newline at end of file
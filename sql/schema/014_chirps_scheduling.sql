@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN published BOOLEAN NOT NULL DEFAULT true,
+ADD COLUMN publish_at TIMESTAMP;
+
+CREATE INDEX chirps_publish_at_idx ON chirps (publish_at) WHERE published = false;
+
+-- +goose Down
+DELETE FROM chirps WHERE published = false;
+DROP INDEX chirps_publish_at_idx;
+ALTER TABLE chirps
+DROP COLUMN publish_at,
+DROP COLUMN published;
\ No newline at end of file
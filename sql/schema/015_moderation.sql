@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE moderation_rules (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	term TEXT NOT NULL UNIQUE,
+	action TEXT NOT NULL CHECK (action IN ('mask', 'hold', 'reject'))
+);
+
+-- The words getCleanedBody used to mask.
+INSERT INTO moderation_rules (term, action)
+VALUES ('kerfuffle', 'mask'), ('sharbert', 'mask'), ('fornax', 'mask');
+
+ALTER TABLE chirps
+ADD COLUMN original_body TEXT,
+ADD COLUMN moderation_status TEXT NOT NULL DEFAULT 'approved'
+	CHECK (moderation_status IN ('approved', 'held', 'rejected'));
+
+CREATE INDEX chirps_moderation_held_idx ON chirps (created_at, id) WHERE moderation_status = 'held';
+
+-- +goose Down
+DELETE FROM chirps WHERE moderation_status <> 'approved';
+DROP INDEX chirps_moderation_held_idx;
+ALTER TABLE chirps
+DROP COLUMN moderation_status,
+DROP COLUMN original_body;
+DROP TABLE moderation_rules;
\ No newline at end of file
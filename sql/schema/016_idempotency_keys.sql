@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE idempotency_keys (
+	scope TEXT NOT NULL,
+	key TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expires_at TIMESTAMP NOT NULL,
+	request_hash TEXT NOT NULL,
+	status_code INTEGER,
+	content_type TEXT NOT NULL DEFAULT '',
+	response_body BYTEA NOT NULL DEFAULT '',
+	PRIMARY KEY (scope, key)
+);
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+-- Retries are now caught by idempotency keys, so different users, or the
+-- same user on different days, may post the same text.
+DROP INDEX chirps_body_live_key;
+
+-- +goose Down
+CREATE UNIQUE INDEX chirps_body_live_key ON chirps (body)
+WHERE deleted_at IS NULL AND rechirp_of_id IS NULL;
+DROP TABLE idempotency_keys;
\ No newline at end of file
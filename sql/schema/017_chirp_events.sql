@@ -0,0 +1,6 @@
+-- +goose Up
+-- Orders streamed chirp events across server instances.
+CREATE SEQUENCE chirp_event_id_seq;
+
+-- +goose Down
+DROP SEQUENCE chirp_event_id_seq;
\ No newline at end of file
@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE conversations (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_activity_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE conversation_members (
+	conversation_id UUID NOT NULL,
+	FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE,
+	user_id UUID NOT NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_read_at TIMESTAMP,
+	PRIMARY KEY (conversation_id, user_id)
+);
+CREATE INDEX conversation_members_user_id_idx ON conversation_members (user_id);
+
+CREATE TABLE direct_messages (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	conversation_id UUID NOT NULL,
+	FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE,
+	sender_id UUID NOT NULL,
+	FOREIGN KEY (sender_id) REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	body TEXT NOT NULL,
+	edited_at TIMESTAMP,
+	deleted_at TIMESTAMP
+);
+CREATE INDEX direct_messages_conversation_id_created_at_idx ON direct_messages (conversation_id, created_at, id);
+
+-- +goose Down
+DROP TABLE direct_messages;
+DROP TABLE conversation_members;
+DROP TABLE conversations;
\ No newline at end of file
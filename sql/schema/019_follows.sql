@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE follows (
+	follower_id UUID NOT NULL,
+	FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+	followee_id UUID NOT NULL,
+	FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (follower_id, followee_id),
+	CHECK (follower_id <> followee_id)
+);
+CREATE INDEX follows_follower_id_created_at_idx ON follows (follower_id, created_at, followee_id);
+CREATE INDEX follows_followee_id_created_at_idx ON follows (followee_id, created_at, follower_id);
+
+-- +goose Down
+DROP TABLE follows;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- +goose Up
+CREATE INDEX chirps_timeline_idx ON chirps (user_id, created_at DESC, id DESC)
+	WHERE deleted_at IS NULL AND published;
+
+-- +goose Down
+DROP INDEX chirps_timeline_idx;
\ No newline at end of file
@@ -0,0 +1,48 @@
+-- +goose Up
+CREATE TABLE user_blocks (
+	blocker_id UUID NOT NULL,
+	FOREIGN KEY (blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+	blocked_id UUID NOT NULL,
+	FOREIGN KEY (blocked_id) REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (blocker_id, blocked_id),
+	CHECK (blocker_id <> blocked_id)
+);
+CREATE INDEX user_blocks_blocker_id_created_at_idx ON user_blocks (blocker_id, created_at, blocked_id);
+CREATE INDEX user_blocks_blocked_id_idx ON user_blocks (blocked_id);
+
+CREATE TABLE user_mutes (
+	muter_id UUID NOT NULL,
+	FOREIGN KEY (muter_id) REFERENCES users(id) ON DELETE CASCADE,
+	muted_id UUID NOT NULL,
+	FOREIGN KEY (muted_id) REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (muter_id, muted_id),
+	CHECK (muter_id <> muted_id)
+);
+CREATE INDEX user_mutes_muter_id_created_at_idx ON user_mutes (muter_id, created_at, muted_id);
+
+-- author_hidden_from reports whether a viewer should not see an author's
+-- chirps: either of them has blocked the other, or the viewer has muted the
+-- author. Anonymous viewers see everyone.
+-- +goose StatementBegin
+CREATE FUNCTION author_hidden_from(author_id UUID, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+	SELECT viewer_id IS NOT NULL AND (
+		EXISTS (
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = viewer_id AND blocked_id = author_id)
+				OR (blocker_id = author_id AND blocked_id = viewer_id)
+		)
+		OR EXISTS (
+			SELECT 1 FROM user_mutes
+			WHERE muter_id = viewer_id AND muted_id = author_id
+		)
+	)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION author_hidden_from(UUID, UUID);
+DROP TABLE user_mutes;
+DROP TABLE user_blocks;
\ No newline at end of file
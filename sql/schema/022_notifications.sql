@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE notifications (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	type TEXT NOT NULL CHECK (type IN ('follow', 'mention', 'reply', 'like', 'chirpy_red')),
+	actor_id UUID,
+	FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE,
+	chirp_id UUID,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	read_at TIMESTAMP
+);
+CREATE INDEX notifications_user_id_created_at_idx ON notifications (user_id, created_at, id);
+CREATE INDEX notifications_user_id_unread_idx ON notifications (user_id, created_at, id) WHERE read_at IS NULL;
+
+CREATE TABLE notification_preferences (
+	user_id UUID NOT NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	type TEXT NOT NULL CHECK (type IN ('follow', 'mention', 'reply', 'like', 'chirpy_red')),
+	enabled BOOLEAN NOT NULL,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (user_id, type)
+);
+
+-- +goose Down
+DROP TABLE notification_preferences;
+DROP TABLE notifications;
\ No newline at end of file
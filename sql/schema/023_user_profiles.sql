@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN handle TEXT NOT NULL DEFAULT ('user_' || substr(md5(gen_random_uuid()::text), 1, 10)),
+ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN bio TEXT NOT NULL DEFAULT '',
+ADD COLUMN website TEXT NOT NULL DEFAULT '',
+ADD COLUMN avatar_media_id UUID,
+ADD FOREIGN KEY (avatar_media_id) REFERENCES media_attachments(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX users_handle_key ON users (lower(handle));
+
+-- +goose Down
+DROP INDEX users_handle_key;
+ALTER TABLE users
+DROP COLUMN avatar_media_id,
+DROP COLUMN website,
+DROP COLUMN bio,
+DROP COLUMN display_name,
+DROP COLUMN handle;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN chirpy_red_expires_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN chirpy_red_expires_at;
\ No newline at end of file
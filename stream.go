@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/exy63/chirpy/internal/events"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	chirpEventsChannel   = "chirp_events"
+	chirpEventCreated    = "chirp.created"
+	chirpEventDeleted    = "chirp.deleted"
+	chirpEventHistory    = 1000
+	streamHeartbeatEvery = 15 * time.Second
+	// maxNotifyPayload stays under the 8000 byte limit Postgres puts on
+	// NOTIFY payloads. Bigger events are sent without their data, and
+	// listeners load the chirp themselves.
+	maxNotifyPayload = 7900
+)
+
+// chirpEventMessage is a chirp event as sent through NOTIFY.
+type chirpEventMessage struct {
+	ID      int64           `json:"id"`
+	Type    string          `json:"type"`
+	UserID  uuid.UUID       `json:"user_id"`
+	ChirpID uuid.UUID       `json:"chirp_id"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type DeletedChirpEvent struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// broadcastChirpCreated and broadcastChirpDeleted are called once a change
+// is committed and the chirp is public. Streaming is best effort, so
+// failures are logged rather than failing the request.
+func (cfg *apiConfig) broadcastChirpCreated(ctx context.Context, chirp database.Chirp) {
+	cfg.broadcastChirpEvent(ctx, chirpEventCreated, chirp.UserID, chirp.ID, newChirpResponse(chirp))
+}
+
+func (cfg *apiConfig) broadcastChirpDeleted(ctx context.Context, chirp database.Chirp) {
+	cfg.broadcastChirpEvent(ctx, chirpEventDeleted, chirp.UserID, chirp.ID, DeletedChirpEvent{ID: chirp.ID, UserID: chirp.UserID})
+}
+
+// broadcastChirpEvent hands an event to this instance's hub straight away
+// and to every other instance through NOTIFY. This instance hears the
+// notification too; the hub drops it as a duplicate.
+func (cfg *apiConfig) broadcastChirpEvent(ctx context.Context, eventType string, userID uuid.UUID, chirpID uuid.UUID, data any) {
+	ctx = context.WithoutCancel(ctx)
+
+	id, err := cfg.dbQueries.NextChirpEventID(ctx)
+	if err != nil {
+		log.Printf("Couldn't broadcast %s for chirp %s: %v", eventType, chirpID, err)
+		return
+	}
+	encodedData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Couldn't broadcast %s for chirp %s: %v", eventType, chirpID, err)
+		return
+	}
+
+	cfg.chirpEvents.Publish(events.Event{ID: id, Type: eventType, UserID: userID, Data: encodedData})
+
+	message := chirpEventMessage{ID: id, Type: eventType, UserID: userID, ChirpID: chirpID, Data: encodedData}
+	payload, err := json.Marshal(message)
+	if err == nil && len(payload) > maxNotifyPayload {
+		message.Data = nil
+		payload, err = json.Marshal(message)
+	}
+	if err == nil {
+		err = cfg.dbQueries.NotifyChirpEvent(ctx, string(payload))
+	}
+	if err != nil {
+		log.Printf("Couldn't notify other instances of %s for chirp %s: %v", eventType, chirpID, err)
+	}
+}
+
+// runChirpEventListener feeds events from other instances into the hub
+// until ctx is cancelled. pq.Listener reconnects by itself; events sent
+// while it was disconnected are lost to this instance's clients.
+func (cfg *apiConfig) runChirpEventListener(ctx context.Context, dbURL string) {
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Chirp event listener: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(chirpEventsChannel); err != nil {
+		log.Printf("Couldn't listen for chirp events: %v", err)
+	}
+
+	// The connection is pinged after this long without a notification. One
+	// timer is reset on every pass rather than allocating one per event.
+	const pingInterval = 90 * time.Second
+	ping := time.NewTimer(pingInterval)
+	defer ping.Stop()
+
+	for {
+		ping.Reset(pingInterval)
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-listener.Notify:
+			// A nil notification means the connection was re-established.
+			if notification == nil {
+				continue
+			}
+			cfg.receiveChirpEvent(ctx, notification.Extra)
+		case <-ping.C:
+			go listener.Ping()
+		}
+	}
+}
+
+func (cfg *apiConfig) receiveChirpEvent(ctx context.Context, payload string) {
+	var message chirpEventMessage
+	if err := json.Unmarshal([]byte(payload), &message); err != nil {
+		log.Printf("Ignoring malformed chirp event: %v", err)
+		return
+	}
+
+	data := []byte(message.Data)
+	if len(data) == 0 {
+		chirp, err := cfg.dbQueries.GetChirp(ctx, message.ChirpID)
+		if err != nil {
+			log.Printf("Couldn't load chirp %s for %s: %v", message.ChirpID, message.Type, err)
+			return
+		}
+		data, err = json.Marshal(newChirpResponse(chirp))
+		if err != nil {
+			return
+		}
+	}
+
+	cfg.chirpEvents.Publish(events.Event{ID: message.ID, Type: message.Type, UserID: message.UserID, Data: data})
+}
+
+// handlerStreamChirps pushes chirp.created and chirp.deleted events as
+// Server-Sent Events. Clients that reconnect with Last-Event-ID first get
+// the events they missed, as far back as the hub remembers.
+func (cfg *apiConfig) handlerStreamChirps(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	query := r.URL.Query()
+
+	authorIDs, err := parseAuthorIDs(query["author_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = query.Get("last_event_id")
+	}
+	var resumeFrom int64
+	if lastEventID != "" {
+		resumeFrom, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Last-Event-ID must be an event ID")
+			return
+		}
+	}
+
+	filter := func(event events.Event) bool {
+		return len(authorIDs) == 0 || slices.Contains(authorIDs, event.UserID)
+	}
+	sub, missed := cfg.chirpEvents.Subscribe(filter, resumeFrom, lastEventID != "")
+	defer cfg.chirpEvents.Unsubscribe(sub)
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range missed {
+		writeServerSentEvent(w, event)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatEvery)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			// The hub gave up on us for falling behind; the client
+			// reconnects and resumes.
+			if !ok {
+				return
+			}
+			writeServerSentEvent(w, event)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeServerSentEvent(w http.ResponseWriter, event events.Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type ThreadChirpResponse struct {
+	ChirpResponse
+	Depth int32 `json:"depth"`
+	// Hidden marks a placeholder for a chirp whose author the viewer has
+	// blocked, been blocked by or muted, or for a root whose edit is held
+	// for moderation. Only its position in the thread is kept.
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+type ThreadResponse struct {
+	ConversationID uuid.UUID             `json:"conversation_id"`
+	Chirps         []ThreadChirpResponse `json:"chirps"`
+}
+
+// handlerGetChirpThread returns the whole conversation a chirp belongs to,
+// depth first with siblings oldest first, so clients can render it as is.
+// Chirps hidden from the viewer stay in the tree as placeholders so the
+// replies under them keep their place.
+func (cfg *apiConfig) handlerGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	id := r.PathValue("id")
+	chirpID, err := uuid.Parse(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpID)
+	if err != nil || !chirp.Published {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	threadParams := database.GetChirpThreadParams{
+		RootID:   chirp.RootID,
+		ViewerID: viewerID,
+	}
+	rows, err := cfg.dbQueries.GetChirpThread(r.Context(), threadParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	threadResponse := ThreadResponse{
+		ConversationID: chirp.RootID,
+		Chirps:         make([]ThreadChirpResponse, len(rows)),
+	}
+	chirpResponses := make([]*ChirpResponse, 0, len(rows))
+	for i, row := range rows {
+		if row.Hidden {
+			// The requested chirp itself is hidden just as it is from
+			// GET /api/chirps/{id}.
+			if row.Chirp.ID == chirp.ID {
+				respondWithError(w, http.StatusNotFound, "Chirp not found")
+				return
+			}
+			threadResponse.Chirps[i] = newHiddenThreadChirpResponse(row)
+			continue
+		}
+		// Replies are only followed while published, so this can only be
+		// the root, unpublished again by an edit held for moderation.
+		if !row.Chirp.Published {
+			threadResponse.Chirps[i] = newHiddenThreadChirpResponse(row)
+			continue
+		}
+		threadResponse.Chirps[i] = ThreadChirpResponse{
+			ChirpResponse: newChirpResponse(row.Chirp),
+			Depth:         row.Depth,
+		}
+		chirpResponses = append(chirpResponses, &threadResponse.Chirps[i].ChirpResponse)
+	}
+	if err := cfg.enrichChirps(r.Context(), viewerID, chirpResponses); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, threadResponse)
+}
+
+// newHiddenThreadChirpResponse keeps a hidden chirp's place in the tree
+// without its body, author or anything it shares.
+func newHiddenThreadChirpResponse(row database.GetChirpThreadRow) ThreadChirpResponse {
+	chirpResponse := ChirpResponse{
+		ID:             row.Chirp.ID,
+		CreatedAt:      row.Chirp.CreatedAt,
+		UpdatedAt:      row.Chirp.UpdatedAt,
+		ConversationID: row.Chirp.RootID,
+		Deleted:        row.Chirp.DeletedAt.Valid,
+	}
+	if row.Chirp.ParentID.Valid {
+		chirpResponse.ParentID = &row.Chirp.ParentID.UUID
+	}
+	return ThreadChirpResponse{
+		ChirpResponse: chirpResponse,
+		Depth:         row.Depth,
+		Hidden:        true,
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/exy63/chirpy/internal/auth"
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerGetTimeline returns the chirps of everyone the caller follows, and
+// their own, newest first. The timeline is assembled on read: GetTimeline
+// walks chirps_timeline_idx once per followed author and merges the
+// results, so a page costs the same however many chirps each author has.
+func (cfg *apiConfig) handlerGetTimeline(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.GetTimelineParams{
+		UserID: UserID,
+		Limit:  limit + 1,
+	}
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil || cursor.Direction != cursorNext {
+			respondWithError(w, http.StatusBadRequest, "cursor is invalid")
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	chirps, err := cfg.dbQueries.GetTimeline(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	hasMore := len(chirps) > int(limit)
+	if hasMore {
+		chirps = chirps[:limit]
+	}
+
+	page := ChirpsPageResponse{Chirps: make([]ChirpResponse, len(chirps))}
+	chirpResponses := make([]*ChirpResponse, len(chirps))
+	for i, chirp := range chirps {
+		page.Chirps[i] = newChirpResponse(chirp)
+		chirpResponses[i] = &page.Chirps[i]
+	}
+	if err := cfg.enrichChirps(r.Context(), uuid.NullUUID{UUID: UserID, Valid: true}, chirpResponses); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if hasMore {
+		last := chirps[len(chirps)-1]
+		page.NextCursor = encodeCursor(cursorNext, last.CreatedAt, last.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
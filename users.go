@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
@@ -8,16 +9,41 @@ import (
 
 	"github.com/exy63/chirpy/internal/auth"
 	"github.com/exy63/chirpy/internal/database"
+	"github.com/exy63/chirpy/internal/handles"
 
 	"github.com/google/uuid"
 )
 
 type UserResponse struct {
-	ID          uuid.UUID `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Email       string    `json:"email"`
-	IsChirpyRed bool      `json:"is_chirpy_red"`
+	ID            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	IsChirpyRed   bool      `json:"is_chirpy_red"`
+	// ChirpyRedExpiresAt is unset for memberships that don't expire.
+	ChirpyRedExpiresAt *time.Time `json:"chirpy_red_expires_at,omitempty"`
+	Handle             string     `json:"handle"`
+	DisplayName        string     `json:"display_name"`
+	Bio                string     `json:"bio"`
+	Website            string     `json:"website"`
+	AvatarURL          string     `json:"avatar_url,omitempty"`
+}
+
+// PublicUserResponse is what anyone can see of a user; it leaves out the
+// email address.
+type PublicUserResponse struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	Handle         string    `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	Website        string    `json:"website"`
+	AvatarURL      string    `json:"avatar_url,omitempty"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	FollowedByMe   bool      `json:"followed_by_me"`
 }
 
 func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request) {
@@ -27,6 +53,7 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 	type ParsedRequest struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Handle   string `json:"handle"`
 	}
 	var parsedRequest ParsedRequest
 
@@ -40,6 +67,20 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, http.StatusBadRequest, "Email and Password are required")
 		return
 	}
+	if err := validateEmail(parsedRequest.Email); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Users who don't pick a handle get a generated one they can change later.
+	handleParam := sql.NullString{}
+	if parsedRequest.Handle != "" {
+		if err := handles.Validate(parsedRequest.Handle); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		handleParam = sql.NullString{String: parsedRequest.Handle, Valid: true}
+	}
 
 	hashedPassword, err := auth.HashPassword(parsedRequest.Password)
 	if err != nil {
@@ -55,20 +96,45 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 	params := database.CreateUserParams{
 		Email:          parsedRequest.Email,
 		HashedPassword: hashedPasswordNull,
+		Handle:         handleParam,
 	}
 
-	createdUser, err := cfg.dbQueries.CreateUser(r.Context(), params)
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	createdUser, err := qtx.CreateUser(r.Context(), params)
+	if isUniqueViolation(err) && handleParam.Valid {
+		respondWithError(w, http.StatusConflict, "Handle is already taken")
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	userResponse := UserResponse{
-		ID:          createdUser.ID,
-		CreatedAt:   createdUser.CreatedAt,
-		UpdatedAt:   createdUser.UpdatedAt,
-		Email:       createdUser.Email,
-		IsChirpyRed: createdUser.IsChirpyRed,
+	// The account exists right away but stays restricted until the link
+	// sent here is opened.
+	verificationToken, err := issueEmailVerification(r.Context(), qtx, createdUser.ID, createdUser.Email)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	cfg.sendEmailVerification(r, createdUser.Email, verificationToken)
+
+	userResponse, err := cfg.newUserResponse(r.Context(), createdUser)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
 	respondWithJSON(w, http.StatusCreated, userResponse)
@@ -90,8 +156,13 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request)
 	}
 
 	type Request struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email         string     `json:"email"`
+		Password      string     `json:"password"`
+		Handle        *string    `json:"handle"`
+		DisplayName   *string    `json:"display_name"`
+		Bio           *string    `json:"bio"`
+		Website       *string    `json:"website"`
+		AvatarMediaID *uuid.UUID `json:"avatar_media_id"`
 	}
 	var req Request
 
@@ -101,48 +172,50 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	userFromDb, err := cfg.dbQueries.GetUser(r.Context(), UserID)
-	if err != nil {
-		respondWithError(w, http.StatusNotFound, err.Error())
-		return
+	// PUT keeps its original meaning of an empty email or password as no
+	// change; PATCH /api/users/me is the way to clear fields.
+	patch := userPatch{
+		Handle:      req.Handle,
+		DisplayName: req.DisplayName,
+		Bio:         req.Bio,
+		Website:     req.Website,
 	}
-
-	emailForUpdate := userFromDb.Email
 	if req.Email != "" {
-		emailForUpdate = req.Email
+		patch.Email = &req.Email
 	}
-	passwordForUpdate := userFromDb.HashedPassword
 	if req.Password != "" {
-		hashedPassword, err := auth.HashPassword(req.Password)
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-
-		passwordForUpdate = sql.NullString{
-			String: hashedPassword,
-			Valid:  true,
-		}
+		patch.Password = &req.Password
 	}
-
-	params := database.UpdateUserParams{
-		ID:             UserID,
-		Email:          emailForUpdate,
-		HashedPassword: passwordForUpdate,
+	if req.AvatarMediaID != nil {
+		patch.AvatarMediaID = &uuid.NullUUID{UUID: *req.AvatarMediaID, Valid: true}
 	}
 
-	updatedUser, err := cfg.dbQueries.UpdateUser(r.Context(), params)
+	cfg.updateUser(w, r, UserID, patch, func(ctx context.Context, user database.User) (interface{}, error) {
+		return cfg.newUserResponse(ctx, user)
+	})
+}
+
+func (cfg *apiConfig) handlerGetUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	id := r.PathValue("id")
+	userID, err := uuid.Parse(id)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	userResponse := UserResponse{
-		ID:          updatedUser.ID,
-		CreatedAt:   updatedUser.CreatedAt,
-		UpdatedAt:   updatedUser.UpdatedAt,
-		Email:       updatedUser.Email,
-		IsChirpyRed: updatedUser.IsChirpyRed,
+	user, err := cfg.dbQueries.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	userResponse, err := cfg.newPublicUserResponse(r.Context(), user, cfg.viewerID(r))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
 	respondWithJSON(w, http.StatusOK, userResponse)
@@ -150,13 +223,14 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request)
 
 func (cfg *apiConfig) handlerLoginUser(w http.ResponseWriter, r *http.Request) {
 	type LoginUserResponse struct {
-		ID           uuid.UUID `json:"id"`
-		CreatedAt    time.Time `json:"created_at"`
-		UpdatedAt    time.Time `json:"updated_at"`
-		Email        string    `json:"email"`
-		Token        string    `json:"token"`
-		RefreshToken string    `json:"refresh_token"`
-		IsChirpyRed  bool      `json:"is_chirpy_red"`
+		ID            uuid.UUID `json:"id"`
+		CreatedAt     time.Time `json:"created_at"`
+		UpdatedAt     time.Time `json:"updated_at"`
+		Email         string    `json:"email"`
+		EmailVerified bool      `json:"email_verified"`
+		Token         string    `json:"token"`
+		RefreshToken  string    `json:"refresh_token"`
+		IsChirpyRed   bool      `json:"is_chirpy_red"`
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -208,13 +282,14 @@ func (cfg *apiConfig) handlerLoginUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userResponse := LoginUserResponse{
-		ID:           userFromDb.ID,
-		CreatedAt:    userFromDb.CreatedAt,
-		UpdatedAt:    userFromDb.UpdatedAt,
-		Email:        userFromDb.Email,
-		Token:        accessToken,
-		RefreshToken: refreshToken,
-		IsChirpyRed:  userFromDb.IsChirpyRed,
+		ID:            userFromDb.ID,
+		CreatedAt:     userFromDb.CreatedAt,
+		UpdatedAt:     userFromDb.UpdatedAt,
+		Email:         userFromDb.Email,
+		EmailVerified: userFromDb.EmailVerifiedAt.Valid,
+		Token:         accessToken,
+		RefreshToken:  refreshToken,
+		IsChirpyRed:   hasChirpyRed(userFromDb),
 	}
 
 	respondWithJSON(w, http.StatusOK, userResponse)
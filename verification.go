@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	netmail "net/mail"
+	"net/url"
+	"time"
+
+	"github.com/exy63/chirpy/internal/auth"
+	"github.com/exy63/chirpy/internal/database"
+	"github.com/exy63/chirpy/internal/mail"
+	"github.com/google/uuid"
+)
+
+const (
+	maxEmailLength = 254
+	// emailVerificationTTL is how long a verification link stays valid.
+	emailVerificationTTL = 24 * time.Hour
+	// emailVerificationResendInterval stops the resend endpoint from being
+	// used to flood an inbox.
+	emailVerificationResendInterval = time.Minute
+	// mailSendTimeout bounds how long a request waits on the mail relay.
+	mailSendTimeout = 10 * time.Second
+)
+
+// validateEmail accepts a bare address such as walt@example.com. Display
+// names and comments are rejected so the stored email is what gets mailed.
+func validateEmail(email string) error {
+	if len(email) > maxEmailLength {
+		return errors.New("email is too long")
+	}
+	address, err := netmail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return errors.New("email must be a valid email address")
+	}
+	return nil
+}
+
+// hashEmailVerificationToken is what gets stored, so a leaked table can't
+// be used to verify addresses.
+func hashEmailVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueEmailVerification creates a token proving that userID owns email,
+// replacing any earlier one, with the queries of the caller's transaction.
+// The token should only be mailed once that transaction commits.
+func issueEmailVerification(ctx context.Context, q *database.Queries, userID uuid.UUID, email string) (string, error) {
+	if err := q.DeleteEmailVerificationTokens(ctx, userID); err != nil {
+		return "", err
+	}
+
+	token := auth.MakeRefreshToken()
+	params := database.CreateEmailVerificationTokenParams{
+		TokenHash: hashEmailVerificationToken(token),
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: time.Now().UTC().Add(emailVerificationTTL),
+	}
+	if _, err := q.CreateEmailVerificationToken(ctx, params); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// sendEmailVerification mails a verification link to email. Failures are
+// only logged: the account change is already saved and the user can ask
+// for another link.
+func (cfg *apiConfig) sendEmailVerification(r *http.Request, email, token string) {
+	// The link must never follow the request's Host header, or anyone could
+	// sign up with someone else's address and have the token sent to them.
+	link := cfg.publicBaseURL + "/api/users/verify?token=" + url.QueryEscape(token)
+	msg := mail.Message{
+		To:      email,
+		Subject: "Verify your Chirpy email address",
+		Body: "Confirm that this is your email address by opening the link below:\n\n" +
+			link + "\n\n" +
+			"The link expires in 24 hours. If you didn't ask for this, you can ignore this email.",
+	}
+	// A client hanging up must not cut a delivery short, but a relay that
+	// stops answering must not hold the request forever either.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), mailSendTimeout)
+	defer cancel()
+	if err := cfg.mailer.Send(ctx, msg); err != nil {
+		log.Printf("Couldn't send verification email: %v", err)
+	}
+}
+
+// requireVerifiedEmail writes a 403 and returns false unless userID has
+// verified their email address. Unverified accounts can read but not
+// post, like, follow, upload or message anyone.
+func (cfg *apiConfig) requireVerifiedEmail(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	user, err := cfg.dbQueries.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return false
+	}
+	if !user.EmailVerifiedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "You must verify your email address first")
+		return false
+	}
+	return true
+}
+
+// handlerVerifyEmail confirms the address a token was sent to. For an
+// email change this is the point where the new address replaces the old
+// one.
+func (cfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	verification, err := cfg.dbQueries.GetEmailVerificationToken(r.Context(), hashEmailVerificationToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Verification link is invalid or has expired")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	params := database.VerifyUserEmailParams{
+		ID:    verification.UserID,
+		Email: verification.Email,
+	}
+	user, err := qtx.VerifyUserEmail(r.Context(), params)
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Email is already taken")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := qtx.DeleteEmailVerificationTokens(r.Context(), user.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	userResponse, err := cfg.newUserResponse(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, userResponse)
+}
+
+// handlerResendEmailVerification sends a fresh link for the pending email
+// change, or for the account's own address if it was never verified.
+func (cfg *apiConfig) handlerResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+
+	providedToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "You must provide a token")
+		return
+	}
+	UserID, err := auth.ValidateJWT(providedToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUser(r.Context(), UserID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	email := user.Email
+	pending, err := cfg.dbQueries.GetPendingEmailVerification(r.Context(), user.ID)
+	switch {
+	case err == nil:
+		if time.Since(pending.CreatedAt) < emailVerificationResendInterval {
+			respondWithError(w, http.StatusTooManyRequests, "Wait a minute before asking for another link")
+			return
+		}
+		email = pending.Email
+	case errors.Is(err, sql.ErrNoRows):
+		if user.EmailVerifiedAt.Valid {
+			respondWithError(w, http.StatusConflict, "Email is already verified")
+			return
+		}
+	default:
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	token, err := issueEmailVerification(r.Context(), qtx, user.ID, email)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	cfg.sendEmailVerification(r, email, token)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/exy63/chirpy/internal/auth"
+	"github.com/exy63/chirpy/internal/database"
 	"github.com/google/uuid"
 )
 
+// hasChirpyRed reports whether a user's Chirpy Red membership is active. A
+// membership without an expiry never lapses.
+func hasChirpyRed(user database.User) bool {
+	return user.IsChirpyRed && (!user.ChirpyRedExpiresAt.Valid || user.ChirpyRedExpiresAt.Time.After(time.Now().UTC()))
+}
+
 func (cfg *apiConfig) handlerUpgradeUser(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
@@ -17,7 +26,8 @@ func (cfg *apiConfig) handlerUpgradeUser(w http.ResponseWriter, r *http.Request)
 	}
 
 	type Data struct {
-		UserID string `json:"user_id"`
+		UserID    string     `json:"user_id"`
+		ExpiresAt *time.Time `json:"expires_at"`
 	}
 	type ParsedRequest struct {
 		Event string `json:"event"`
@@ -38,10 +48,41 @@ func (cfg *apiConfig) handlerUpgradeUser(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		if err := cfg.dbQueries.UpgradeUser(r.Context(), userUUID); err != nil {
+		tx, err := cfg.db.BeginTx(r.Context(), nil)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+		qtx := cfg.dbQueries.WithTx(tx)
+
+		user, err := qtx.GetUser(r.Context(), userUUID)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		upgradeParams := database.UpgradeUserParams{
+			ID: user.ID,
+		}
+		if parsedRequest.Data.ExpiresAt != nil {
+			upgradeParams.ChirpyRedExpiresAt = sql.NullTime{Time: parsedRequest.Data.ExpiresAt.UTC(), Valid: true}
+		}
+		if err := qtx.UpgradeUser(r.Context(), upgradeParams); err != nil {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
+		// Polka retries deliveries, so only the first upgrade is announced.
+		if !hasChirpyRed(user) {
+			if err := notify(r.Context(), qtx, user.ID, notificationChirpyRed, uuid.NullUUID{}, uuid.NullUUID{}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 	}
 
 	w.WriteHeader(http.StatusNoContent)